@@ -0,0 +1,101 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fields reads values of a mihomo proxy map. Maps decoded by yaml.v2 nest as map[any]any and
+// numbers may arrive as int, float64 or string, so every getter normalizes its value.
+type fields map[string]any
+
+func newFields(value any) fields {
+	switch typed := value.(type) {
+	case map[string]any:
+		return typed
+	case fields:
+		return typed
+	case map[any]any:
+		converted := make(fields, len(typed))
+		for key, value := range typed {
+			converted[fmt.Sprint(key)] = value
+		}
+		return converted
+	default:
+		return fields{}
+	}
+}
+
+func (f fields) has(key string) bool {
+	value, ok := f[key]
+	return ok && value != nil
+}
+
+func (f fields) str(key string) string {
+	value, ok := f[key]
+	if !ok || value == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(value))
+}
+
+func (f fields) int(key string) int {
+	switch value := f[key].(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case uint64:
+		return int(value)
+	case float64:
+		return int(value)
+	case string:
+		number, _ := strconv.Atoi(strings.TrimSpace(value))
+		return number
+	default:
+		return 0
+	}
+}
+
+func (f fields) bool(key string) bool {
+	switch value := f[key].(type) {
+	case bool:
+		return value
+	case string:
+		parsed, _ := strconv.ParseBool(strings.TrimSpace(value))
+		return parsed
+	default:
+		return false
+	}
+}
+
+func (f fields) sub(key string) fields {
+	return newFields(f[key])
+}
+
+// strings returns a list value; a single string is treated as a one-element list.
+func (f fields) strings(key string) []string {
+	switch value := f[key].(type) {
+	case []string:
+		return value
+	case []any:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			list = append(list, fmt.Sprint(item))
+		}
+		return list
+	case string:
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	default:
+		return nil
+	}
+}
+
+// name is the node name used in errors.
+func (f fields) name() string {
+	return f.str("name")
+}
@@ -0,0 +1,36 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExportFormat is the format tested nodes are written in.
+type ExportFormat string
+
+const (
+	// ExportClash is a mihomo config with a proxies list.
+	ExportClash ExportFormat = "clash"
+	// ExportSingBox is a sing-box config with an outbounds list.
+	ExportSingBox ExportFormat = "singbox"
+	// ExportURI is a plain list of share URIs, one per line.
+	ExportURI ExportFormat = "uri"
+	// ExportBase64 is the share URI list base64 encoded, the usual subscription format.
+	ExportBase64 ExportFormat = "base64"
+)
+
+func ParseExportFormat(value string) (ExportFormat, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch ExportFormat(normalized) {
+	case "", ExportClash, "mihomo":
+		return ExportClash, nil
+	case ExportSingBox, "sing-box":
+		return ExportSingBox, nil
+	case ExportURI:
+		return ExportURI, nil
+	case ExportBase64:
+		return ExportBase64, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", value)
+	}
+}
@@ -0,0 +1,273 @@
+package convert
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ShareLinks encodes proxies as share URIs, one per line, for clients such as v2rayN,
+// Shadowrocket and NekoBox. With encode set the list is base64 encoded as subscriptions
+// usually are. Nodes without a share URI scheme are returned as skipped.
+func ShareLinks(proxies []map[string]any, encode bool) ([]byte, []Skipped) {
+	links := make([]string, 0, len(proxies))
+	var skipped []Skipped
+	for _, proxy := range proxies {
+		link, err := ToShareLink(proxy)
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: newFields(proxy).name(), Err: err})
+			continue
+		}
+		links = append(links, link)
+	}
+	list := strings.Join(links, "\n")
+	if encode {
+		return []byte(base64.StdEncoding.EncodeToString([]byte(list))), skipped
+	}
+	if list != "" {
+		list += "\n"
+	}
+	return []byte(list), skipped
+}
+
+// ToShareLink encodes one mihomo proxy map as a share URI with the node name as fragment.
+func ToShareLink(proxy map[string]any) (string, error) {
+	f := newFields(proxy)
+	if f.name() == "" || f.str("server") == "" {
+		return "", fmt.Errorf("missing name or server")
+	}
+	link := &url.URL{
+		Host:     net.JoinHostPort(f.str("server"), strconv.Itoa(f.int("port"))),
+		Fragment: f.name(),
+	}
+	query := url.Values{}
+
+	switch proxyType := strings.ToLower(f.str("type")); proxyType {
+	case "ss":
+		return shadowsocksLink(f, link)
+	case "vmess":
+		return vmessLink(f)
+	case "vless":
+		link.Scheme = "vless"
+		link.User = url.User(f.str("uuid"))
+		query.Set("encryption", "none")
+		if flow := f.str("flow"); flow != "" {
+			query.Set("flow", flow)
+		}
+		if encoding := f.str("packet-encoding"); encoding != "" {
+			query.Set("packetEncoding", encoding)
+		}
+		security := "none"
+		if f.bool("tls") {
+			security = "tls"
+			if f.sub("reality-opts").str("public-key") != "" {
+				security = "reality"
+			}
+		}
+		setSecurityQuery(f, query, security, "servername")
+		if err := setTransportQuery(f, query); err != nil {
+			return "", err
+		}
+	case "trojan":
+		link.Scheme = "trojan"
+		link.User = url.User(f.str("password"))
+		setSecurityQuery(f, query, "tls", "sni")
+		if err := setTransportQuery(f, query); err != nil {
+			return "", err
+		}
+	case "hysteria2":
+		link.Scheme = "hysteria2"
+		link.User = url.User(f.str("password"))
+		if sni := f.str("sni"); sni != "" {
+			query.Set("sni", sni)
+		}
+		if f.bool("skip-cert-verify") {
+			query.Set("insecure", "1")
+		}
+		if obfs := f.str("obfs"); obfs != "" {
+			query.Set("obfs", obfs)
+			query.Set("obfs-password", f.str("obfs-password"))
+		}
+		if ports := f.str("ports"); ports != "" {
+			query.Set("mport", ports)
+		}
+		link.Path = "/"
+	case "tuic":
+		if f.str("token") != "" {
+			return "", fmt.Errorf("tuic v4 token authentication has no share URI")
+		}
+		link.Scheme = "tuic"
+		link.User = url.UserPassword(f.str("uuid"), f.str("password"))
+		if sni := f.str("sni"); sni != "" {
+			query.Set("sni", sni)
+		}
+		if alpn := f.strings("alpn"); len(alpn) > 0 {
+			query.Set("alpn", strings.Join(alpn, ","))
+		}
+		if congestion := f.str("congestion-controller"); congestion != "" {
+			query.Set("congestion_control", congestion)
+		}
+		if relay := f.str("udp-relay-mode"); relay != "" {
+			query.Set("udp_relay_mode", relay)
+		}
+		if f.bool("skip-cert-verify") {
+			query.Set("allow_insecure", "1")
+		}
+	default:
+		return "", fmt.Errorf("proxy type %q has no share URI", proxyType)
+	}
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// shadowsocksLink builds a SIP002 URI: ss://base64url(method:password)@host:port/?plugin=...#name.
+// SIP022 forbids the base64 user info for 2022-blake3-* ciphers, so they get method:password
+// percent-encoded instead.
+func shadowsocksLink(f fields, link *url.URL) (string, error) {
+	link.Scheme = "ss"
+	if cipher := f.str("cipher"); strings.HasPrefix(cipher, "2022-") {
+		link.User = url.UserPassword(cipher, f.str("password"))
+	} else {
+		link.User = url.User(base64.RawURLEncoding.EncodeToString([]byte(cipher + ":" + f.str("password"))))
+	}
+	if plugin := f.str("plugin"); plugin != "" {
+		opts := f.sub("plugin-opts")
+		var value string
+		switch plugin {
+		case "obfs":
+			value = joinPluginOpts("obfs-local", "obfs="+opts.str("mode"), "obfs-host="+opts.str("host"))
+		case "v2ray-plugin":
+			parts := []string{"v2ray-plugin", "mode=" + orDefault(opts.str("mode"), "websocket"), "host=" + opts.str("host"), "path=" + opts.str("path")}
+			if opts.bool("tls") {
+				parts = append(parts, "tls")
+			}
+			value = joinPluginOpts(parts...)
+		default:
+			return "", fmt.Errorf("shadowsocks plugin %q has no share URI", plugin)
+		}
+		link.Path = "/"
+		link.RawQuery = url.Values{"plugin": {value}}.Encode()
+	}
+	return link.String(), nil
+}
+
+// vmessLink builds the v2rayN vmess:// URI, a base64 encoded JSON object.
+func vmessLink(f fields) (string, error) {
+	network := orDefault(strings.ToLower(f.str("network")), "tcp")
+	config := map[string]string{
+		"v":    "2",
+		"ps":   f.name(),
+		"add":  f.str("server"),
+		"port": strconv.Itoa(f.int("port")),
+		"id":   f.str("uuid"),
+		"aid":  strconv.Itoa(f.int("alterId")),
+		"scy":  orDefault(f.str("cipher"), "auto"),
+		"net":  network,
+		"type": "none",
+	}
+	if f.bool("tls") {
+		config["tls"] = "tls"
+		config["sni"] = f.str("servername")
+		if alpn := f.strings("alpn"); len(alpn) > 0 {
+			config["alpn"] = strings.Join(alpn, ",")
+		}
+		if fingerprint := f.str("client-fingerprint"); fingerprint != "" {
+			config["fp"] = fingerprint
+		}
+	}
+	switch network {
+	case "tcp":
+	case "ws":
+		opts := f.sub("ws-opts")
+		config["path"] = opts.str("path")
+		config["host"] = opts.sub("headers").str("Host")
+	case "grpc":
+		config["path"] = f.sub("grpc-opts").str("grpc-service-name")
+	case "h2":
+		opts := f.sub("h2-opts")
+		config["path"] = opts.str("path")
+		config["host"] = strings.Join(opts.strings("host"), ",")
+	case "http":
+		opts := f.sub("http-opts")
+		config["type"] = "http"
+		config["path"] = strings.Join(opts.strings("path"), ",")
+		config["host"] = strings.Join(opts.sub("headers").strings("Host"), ",")
+	default:
+		return "", fmt.Errorf("transport %q has no share URI", network)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// setSecurityQuery adds the TLS or REALITY parameters of vless and trojan URIs.
+func setSecurityQuery(f fields, query url.Values, security, sniKey string) {
+	query.Set("security", security)
+	if security == "none" {
+		return
+	}
+	if sni := f.str(sniKey); sni != "" {
+		query.Set("sni", sni)
+	}
+	if f.bool("skip-cert-verify") {
+		query.Set("allowInsecure", "1")
+	}
+	if alpn := f.strings("alpn"); len(alpn) > 0 {
+		query.Set("alpn", strings.Join(alpn, ","))
+	}
+	if fingerprint := f.str("client-fingerprint"); fingerprint != "" {
+		query.Set("fp", fingerprint)
+	}
+	if reality := f.sub("reality-opts"); security == "reality" {
+		query.Set("pbk", reality.str("public-key"))
+		if shortID := reality.str("short-id"); shortID != "" {
+			query.Set("sid", shortID)
+		}
+	}
+}
+
+// setTransportQuery adds the v2ray transport parameters of vless and trojan URIs.
+func setTransportQuery(f fields, query url.Values) error {
+	network := orDefault(strings.ToLower(f.str("network")), "tcp")
+	query.Set("type", network)
+	switch network {
+	case "tcp":
+	case "ws":
+		opts := f.sub("ws-opts")
+		if path := opts.str("path"); path != "" {
+			query.Set("path", path)
+		}
+		if host := opts.sub("headers").str("Host"); host != "" {
+			query.Set("host", host)
+		}
+	case "grpc":
+		query.Set("serviceName", f.sub("grpc-opts").str("grpc-service-name"))
+	case "h2":
+		opts := f.sub("h2-opts")
+		if path := opts.str("path"); path != "" {
+			query.Set("path", path)
+		}
+		if hosts := opts.strings("host"); len(hosts) > 0 {
+			query.Set("host", strings.Join(hosts, ","))
+		}
+	case "http":
+		opts := f.sub("http-opts")
+		query.Set("type", "tcp")
+		query.Set("headerType", "http")
+		if paths := opts.strings("path"); len(paths) > 0 {
+			query.Set("path", paths[0])
+		}
+		if hosts := opts.sub("headers").strings("Host"); len(hosts) > 0 {
+			query.Set("host", strings.Join(hosts, ","))
+		}
+	default:
+		return fmt.Errorf("transport %q has no share URI", network)
+	}
+	return nil
+}
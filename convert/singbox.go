@@ -0,0 +1,482 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SingBoxGroup is an optional group outbound placed in front of the exported nodes.
+type SingBoxGroup string
+
+const (
+	SingBoxGroupNone     SingBoxGroup = "none"
+	SingBoxGroupURLTest  SingBoxGroup = "urltest"
+	SingBoxGroupSelector SingBoxGroup = "selector"
+)
+
+const (
+	singBoxGroupTag     = "proxy"
+	singBoxURLTestURL   = "https://www.gstatic.com/generate_204"
+	singBoxTestInterval = "3m"
+)
+
+func ParseSingBoxGroup(value string) (SingBoxGroup, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch SingBoxGroup(normalized) {
+	case "", SingBoxGroupNone:
+		return SingBoxGroupNone, nil
+	case SingBoxGroupURLTest:
+		return SingBoxGroupURLTest, nil
+	case SingBoxGroupSelector:
+		return SingBoxGroupSelector, nil
+	default:
+		return "", fmt.Errorf("unsupported sing-box group %q", value)
+	}
+}
+
+// Skipped is a node left out of an export because its fields have no equivalent in the target format.
+type Skipped struct {
+	Name string
+	Err  error
+}
+
+// SingBoxConfig converts mihomo proxy maps into a sing-box config holding only outbounds and
+// endpoints, led by group unless it is SingBoxGroupNone or no node could be converted. WireGuard
+// nodes become endpoints, which replaced WireGuard outbounds in sing-box 1.11. Nodes that cannot
+// be mapped are returned as skipped.
+func SingBoxConfig(proxies []map[string]any, group SingBoxGroup) ([]byte, []Skipped, error) {
+	outbounds := make([]map[string]any, 0, len(proxies)+1)
+	var endpoints []map[string]any
+	tags := make([]string, 0, len(proxies))
+	var skipped []Skipped
+	for _, proxy := range proxies {
+		wireGuard := strings.EqualFold(newFields(proxy).str("type"), "wireguard")
+		var converted map[string]any
+		var err error
+		if wireGuard {
+			converted, err = ToSingBoxEndpoint(proxy)
+		} else {
+			converted, err = ToSingBoxOutbound(proxy)
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{Name: newFields(proxy).name(), Err: err})
+			continue
+		}
+		if wireGuard {
+			endpoints = append(endpoints, converted)
+		} else {
+			outbounds = append(outbounds, converted)
+		}
+		tags = append(tags, converted["tag"].(string))
+	}
+
+	// sing-box rejects a group without members, so a config with no nodes gets no group either.
+	if len(tags) > 0 {
+		switch group {
+		case SingBoxGroupURLTest:
+			outbounds = append([]map[string]any{{
+				"type":      "urltest",
+				"tag":       singBoxGroupTag,
+				"outbounds": tags,
+				"url":       singBoxURLTestURL,
+				"interval":  singBoxTestInterval,
+			}}, outbounds...)
+		case SingBoxGroupSelector:
+			outbounds = append([]map[string]any{{
+				"type":      "selector",
+				"tag":       singBoxGroupTag,
+				"outbounds": tags,
+				"default":   tags[0],
+			}}, outbounds...)
+		}
+	}
+
+	config := map[string]any{"outbounds": outbounds}
+	if len(endpoints) > 0 {
+		config["endpoints"] = endpoints
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, skipped, err
+	}
+	return append(data, '\n'), skipped, nil
+}
+
+// ToSingBoxOutbound converts one mihomo proxy map into a sing-box outbound tagged with its name.
+func ToSingBoxOutbound(proxy map[string]any) (map[string]any, error) {
+	f := newFields(proxy)
+	if f.name() == "" || f.str("server") == "" {
+		return nil, fmt.Errorf("missing name or server")
+	}
+	outbound := map[string]any{
+		"tag":         f.name(),
+		"server":      f.str("server"),
+		"server_port": f.int("port"),
+	}
+
+	var err error
+	switch proxyType := strings.ToLower(f.str("type")); proxyType {
+	case "ss":
+		err = singBoxShadowsocks(f, outbound)
+	case "vmess":
+		outbound["type"] = "vmess"
+		outbound["uuid"] = f.str("uuid")
+		outbound["alter_id"] = f.int("alterId")
+		outbound["security"] = orDefault(f.str("cipher"), "auto")
+		err = singBoxStreamSettings(f, outbound, "servername", f.bool("tls"))
+	case "vless":
+		outbound["type"] = "vless"
+		outbound["uuid"] = f.str("uuid")
+		if flow := f.str("flow"); flow != "" {
+			outbound["flow"] = flow
+		}
+		if f.str("packet-encoding") != "" {
+			outbound["packet_encoding"] = f.str("packet-encoding")
+		}
+		err = singBoxStreamSettings(f, outbound, "servername", f.bool("tls"))
+	case "trojan":
+		outbound["type"] = "trojan"
+		outbound["password"] = f.str("password")
+		err = singBoxStreamSettings(f, outbound, "sni", true)
+	case "hysteria2":
+		outbound["type"] = "hysteria2"
+		outbound["password"] = f.str("password")
+		if ports := f.str("ports"); ports != "" {
+			outbound["server_ports"] = singBoxPortRanges(ports)
+		}
+		setMbps(f, outbound, "up", "up_mbps")
+		setMbps(f, outbound, "down", "down_mbps")
+		if obfs := f.str("obfs"); obfs != "" {
+			outbound["obfs"] = map[string]any{"type": obfs, "password": f.str("obfs-password")}
+		}
+		outbound["tls"] = singBoxTLS(f, "sni", true)
+	case "hysteria":
+		outbound["type"] = "hysteria"
+		setMbps(f, outbound, "up", "up_mbps")
+		setMbps(f, outbound, "down", "down_mbps")
+		if auth := f.str("auth-str"); auth != "" {
+			outbound["auth_str"] = auth
+		}
+		if obfs := f.str("obfs"); obfs != "" {
+			outbound["obfs"] = obfs
+		}
+		if protocol := f.str("protocol"); protocol != "" && protocol != "udp" {
+			err = fmt.Errorf("hysteria protocol %q is not supported by sing-box", protocol)
+		}
+		outbound["tls"] = singBoxTLS(f, "sni", true)
+	case "tuic":
+		if f.str("token") != "" {
+			return nil, fmt.Errorf("tuic v4 token authentication is not supported by sing-box")
+		}
+		outbound["type"] = "tuic"
+		outbound["uuid"] = f.str("uuid")
+		outbound["password"] = f.str("password")
+		if congestion := f.str("congestion-controller"); congestion != "" {
+			outbound["congestion_control"] = congestion
+		}
+		if relay := f.str("udp-relay-mode"); relay != "" {
+			outbound["udp_relay_mode"] = relay
+		}
+		outbound["tls"] = singBoxTLS(f, "sni", true)
+	case "wireguard":
+		return nil, fmt.Errorf("wireguard is a sing-box endpoint, not an outbound")
+	case "socks5":
+		outbound["type"] = "socks"
+		outbound["version"] = "5"
+		setCredentials(f, outbound)
+		if f.bool("tls") {
+			err = fmt.Errorf("socks5 over tls is not supported by sing-box")
+		}
+	case "http":
+		outbound["type"] = "http"
+		setCredentials(f, outbound)
+		if f.bool("tls") {
+			outbound["tls"] = singBoxTLS(f, "sni", true)
+		}
+	case "ssh":
+		outbound["type"] = "ssh"
+		outbound["user"] = f.str("username")
+		if password := f.str("password"); password != "" {
+			outbound["password"] = password
+		}
+		if key := f.str("private-key"); key != "" {
+			outbound["private_key"] = key
+		}
+	case "anytls":
+		outbound["type"] = "anytls"
+		outbound["password"] = f.str("password")
+		outbound["tls"] = singBoxTLS(f, "sni", true)
+	default:
+		return nil, fmt.Errorf("proxy type %q has no sing-box outbound", proxyType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return outbound, nil
+}
+
+func singBoxShadowsocks(f fields, outbound map[string]any) error {
+	outbound["type"] = "shadowsocks"
+	outbound["method"] = f.str("cipher")
+	outbound["password"] = f.str("password")
+	if f.bool("udp-over-tcp") {
+		outbound["udp_over_tcp"] = true
+	}
+	plugin := f.str("plugin")
+	if plugin == "" {
+		return nil
+	}
+	opts := f.sub("plugin-opts")
+	switch plugin {
+	case "obfs":
+		outbound["plugin"] = "obfs-local"
+		outbound["plugin_opts"] = joinPluginOpts("obfs="+opts.str("mode"), "obfs-host="+opts.str("host"))
+	case "v2ray-plugin":
+		if mode := opts.str("mode"); mode != "" && mode != "websocket" {
+			return fmt.Errorf("v2ray-plugin mode %q is not supported by sing-box", mode)
+		}
+		parts := []string{"mode=websocket", "host=" + opts.str("host"), "path=" + opts.str("path")}
+		if opts.bool("tls") {
+			parts = append(parts, "tls")
+		}
+		outbound["plugin"] = "v2ray-plugin"
+		outbound["plugin_opts"] = joinPluginOpts(parts...)
+	default:
+		return fmt.Errorf("shadowsocks plugin %q is not supported by sing-box", plugin)
+	}
+	return nil
+}
+
+// joinPluginOpts joins SIP003 options, leaving out empty key=value pairs.
+func joinPluginOpts(parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if strings.HasSuffix(part, "=") {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	return strings.Join(kept, ";")
+}
+
+// ToSingBoxEndpoint converts a mihomo wireguard proxy into a sing-box WireGuard endpoint tagged
+// with its name. Only single peer nodes are supported.
+func ToSingBoxEndpoint(proxy map[string]any) (map[string]any, error) {
+	f := newFields(proxy)
+	if f.name() == "" || f.str("server") == "" {
+		return nil, fmt.Errorf("missing name or server")
+	}
+	if proxyType := strings.ToLower(f.str("type")); proxyType != "wireguard" {
+		return nil, fmt.Errorf("proxy type %q has no sing-box endpoint", proxyType)
+	}
+	if f.has("peers") {
+		return nil, fmt.Errorf("wireguard with multiple peers is not supported")
+	}
+	var addresses []string
+	if address := f.str("ip"); address != "" {
+		addresses = append(addresses, withPrefix(address, "/32"))
+	}
+	if address := f.str("ipv6"); address != "" {
+		addresses = append(addresses, withPrefix(address, "/128"))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("wireguard local address is missing")
+	}
+	allowedIPs := f.strings("allowed-ips")
+	if len(allowedIPs) == 0 {
+		allowedIPs = []string{"0.0.0.0/0", "::/0"}
+	}
+	peer := map[string]any{
+		"address":     f.str("server"),
+		"port":        f.int("port"),
+		"public_key":  f.str("public-key"),
+		"allowed_ips": allowedIPs,
+	}
+	if key := f.str("pre-shared-key"); key != "" {
+		peer["pre_shared_key"] = key
+	}
+	if keepalive := f.int("persistent-keepalive"); keepalive > 0 {
+		peer["persistent_keepalive_interval"] = keepalive
+	}
+	if reserved, err := wireGuardReserved(f["reserved"]); err != nil {
+		return nil, err
+	} else if reserved != nil {
+		peer["reserved"] = reserved
+	}
+	endpoint := map[string]any{
+		"type":        "wireguard",
+		"tag":         f.name(),
+		"address":     addresses,
+		"private_key": f.str("private-key"),
+		"peers":       []map[string]any{peer},
+	}
+	if mtu := f.int("mtu"); mtu > 0 {
+		endpoint["mtu"] = mtu
+	}
+	return endpoint, nil
+}
+
+func withPrefix(address, prefix string) string {
+	if strings.Contains(address, "/") {
+		return address
+	}
+	return address + prefix
+}
+
+// wireGuardReserved accepts the three reserved bytes as a list or a "1,2,3" string.
+func wireGuardReserved(value any) ([]int, error) {
+	var parts []string
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		parts = strings.Split(typed, ",")
+	case []any:
+		for _, item := range typed {
+			parts = append(parts, fmt.Sprint(item))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported wireguard reserved value %v", value)
+	}
+	reserved := make([]int, 0, len(parts))
+	for _, part := range parts {
+		number, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid wireguard reserved value %v", value)
+		}
+		reserved = append(reserved, number)
+	}
+	return reserved, nil
+}
+
+// singBoxStreamSettings maps TLS, REALITY and the v2ray transport of vmess, vless and trojan nodes.
+func singBoxStreamSettings(f fields, outbound map[string]any, sniKey string, tls bool) error {
+	if tls {
+		outbound["tls"] = singBoxTLS(f, sniKey, true)
+	}
+	transport, err := singBoxTransport(f)
+	if err != nil {
+		return err
+	}
+	if transport != nil {
+		outbound["transport"] = transport
+	}
+	return nil
+}
+
+func singBoxTLS(f fields, sniKey string, enabled bool) map[string]any {
+	tls := map[string]any{"enabled": enabled}
+	if sni := f.str(sniKey); sni != "" {
+		tls["server_name"] = sni
+	}
+	if f.bool("skip-cert-verify") {
+		tls["insecure"] = true
+	}
+	if alpn := f.strings("alpn"); len(alpn) > 0 {
+		tls["alpn"] = alpn
+	}
+	if fingerprint := f.str("client-fingerprint"); fingerprint != "" {
+		tls["utls"] = map[string]any{"enabled": true, "fingerprint": fingerprint}
+	}
+	if reality := f.sub("reality-opts"); reality.str("public-key") != "" {
+		tls["reality"] = map[string]any{
+			"enabled":    true,
+			"public_key": reality.str("public-key"),
+			"short_id":   reality.str("short-id"),
+		}
+	}
+	return tls
+}
+
+func singBoxTransport(f fields) (map[string]any, error) {
+	switch network := strings.ToLower(f.str("network")); network {
+	case "", "tcp":
+		return nil, nil
+	case "ws":
+		opts := f.sub("ws-opts")
+		transport := map[string]any{"type": "ws", "path": orDefault(opts.str("path"), "/")}
+		if host := opts.sub("headers").str("Host"); host != "" {
+			transport["headers"] = map[string]any{"Host": host}
+		}
+		if early := opts.int("max-early-data"); early > 0 {
+			transport["max_early_data"] = early
+			transport["early_data_header_name"] = orDefault(opts.str("early-data-header-name"), "Sec-WebSocket-Protocol")
+		}
+		return transport, nil
+	case "grpc":
+		return map[string]any{"type": "grpc", "service_name": f.sub("grpc-opts").str("grpc-service-name")}, nil
+	case "h2":
+		opts := f.sub("h2-opts")
+		transport := map[string]any{"type": "http", "path": orDefault(opts.str("path"), "/")}
+		if hosts := opts.strings("host"); len(hosts) > 0 {
+			transport["host"] = hosts
+		}
+		return transport, nil
+	case "http":
+		opts := f.sub("http-opts")
+		transport := map[string]any{"type": "http", "method": orDefault(opts.str("method"), "GET")}
+		if paths := opts.strings("path"); len(paths) > 0 {
+			transport["path"] = paths[0]
+		}
+		if hosts := opts.sub("headers").strings("Host"); len(hosts) > 0 {
+			transport["host"] = hosts
+		}
+		return transport, nil
+	default:
+		return nil, fmt.Errorf("transport %q is not supported by sing-box", network)
+	}
+}
+
+// singBoxPortRanges converts mihomo hopping ports such as "443,1000-2000" to sing-box "1000:2000" ranges.
+func singBoxPortRanges(ports string) []string {
+	var ranges []string
+	for _, part := range strings.Split(ports, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "-") {
+			part = part + "-" + part
+		}
+		ranges = append(ranges, strings.Replace(part, "-", ":", 1))
+	}
+	return ranges
+}
+
+// setMbps copies a mihomo bandwidth such as "100 Mbps" or 100 into an integer Mbps field.
+func setMbps(f fields, outbound map[string]any, key, target string) {
+	value := strings.ToLower(f.str(key))
+	if value == "" {
+		return
+	}
+	number := strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyz /")
+	mbps, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || mbps <= 0 {
+		return
+	}
+	switch unit := strings.TrimSpace(strings.TrimPrefix(value, number)); {
+	case strings.HasPrefix(unit, "g"):
+		mbps *= 1000
+	case strings.HasPrefix(unit, "k"):
+		mbps /= 1000
+	}
+	outbound[target] = max(int(mbps), 1)
+}
+
+func setCredentials(f fields, outbound map[string]any) {
+	if username := f.str("username"); username != "" {
+		outbound["username"] = username
+	}
+	if password := f.str("password"); password != "" {
+		outbound["password"] = password
+	}
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
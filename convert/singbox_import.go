@@ -0,0 +1,262 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// singBoxNonProxyTypes are outbound types that do not describe a node, such as groups.
+var singBoxNonProxyTypes = map[string]bool{
+	"direct": true, "block": true, "dns": true, "selector": true, "urltest": true,
+}
+
+func parseSingBoxOutbounds(body []byte) ([]map[string]any, []error) {
+	var config struct {
+		Outbounds []map[string]any `json:"outbounds"`
+	}
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, []error{fmt.Errorf("invalid sing-box config: %w", err)}
+	}
+	var proxies []map[string]any
+	var errs []error
+	for i, outbound := range config.Outbounds {
+		if singBoxNonProxyTypes[newFields(outbound).str("type")] {
+			continue
+		}
+		proxy, err := FromSingBoxOutbound(outbound)
+		if err != nil {
+			errs = append(errs, &OutboundError{Index: i + 1, Err: err})
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, errs
+}
+
+// FromSingBoxOutbound converts a sing-box outbound into a mihomo proxy map, the reverse of ToSingBoxOutbound.
+func FromSingBoxOutbound(outbound map[string]any) (map[string]any, error) {
+	f := newFields(outbound)
+	if f.str("tag") == "" || f.str("server") == "" {
+		return nil, fmt.Errorf("missing tag or server")
+	}
+	proxy := map[string]any{
+		"name":   f.str("tag"),
+		"server": f.str("server"),
+		"port":   f.int("server_port"),
+		"udp":    true,
+	}
+	tls := f.sub("tls")
+
+	var err error
+	switch outboundType := f.str("type"); outboundType {
+	case "shadowsocks":
+		proxy["type"] = "ss"
+		proxy["cipher"] = f.str("method")
+		proxy["password"] = f.str("password")
+		if f.bool("udp_over_tcp") {
+			proxy["udp-over-tcp"] = true
+		}
+		if plugin := f.str("plugin"); plugin != "" {
+			name, opts, pluginErr := parsePluginOpts(plugin + ";" + f.str("plugin_opts"))
+			if pluginErr != nil {
+				return nil, pluginErr
+			}
+			proxy["plugin"] = name
+			proxy["plugin-opts"] = opts
+		}
+	case "vmess":
+		proxy["type"] = "vmess"
+		proxy["uuid"] = f.str("uuid")
+		proxy["alterId"] = f.int("alter_id")
+		proxy["cipher"] = orDefault(f.str("security"), "auto")
+		setMihomoTLS(proxy, tls, "servername", false)
+		err = setMihomoTransport(proxy, f.sub("transport"), tls.bool("enabled"))
+	case "vless":
+		proxy["type"] = "vless"
+		proxy["uuid"] = f.str("uuid")
+		if flow := f.str("flow"); flow != "" {
+			proxy["flow"] = flow
+		}
+		if encoding := f.str("packet_encoding"); encoding != "" {
+			proxy["packet-encoding"] = encoding
+		}
+		setMihomoTLS(proxy, tls, "servername", false)
+		err = setMihomoTransport(proxy, f.sub("transport"), tls.bool("enabled"))
+	case "trojan":
+		proxy["type"] = "trojan"
+		proxy["password"] = f.str("password")
+		setMihomoTLS(proxy, tls, "sni", true)
+		err = setMihomoTransport(proxy, f.sub("transport"), true)
+	case "hysteria2":
+		proxy["type"] = "hysteria2"
+		proxy["password"] = f.str("password")
+		if ports := f.strings("server_ports"); len(ports) > 0 {
+			proxy["ports"] = strings.ReplaceAll(strings.Join(ports, ","), ":", "-")
+		}
+		setMihomoBandwidth(proxy, f)
+		if obfs := f.sub("obfs"); obfs.str("type") != "" {
+			proxy["obfs"] = obfs.str("type")
+			proxy["obfs-password"] = obfs.str("password")
+		}
+		setMihomoTLS(proxy, tls, "sni", true)
+	case "hysteria":
+		proxy["type"] = "hysteria"
+		setMihomoBandwidth(proxy, f)
+		if auth := f.str("auth_str"); auth != "" {
+			proxy["auth-str"] = auth
+		}
+		if obfs := f.str("obfs"); obfs != "" {
+			proxy["obfs"] = obfs
+		}
+		setMihomoTLS(proxy, tls, "sni", true)
+	case "tuic":
+		proxy["type"] = "tuic"
+		proxy["uuid"] = f.str("uuid")
+		proxy["password"] = f.str("password")
+		if congestion := f.str("congestion_control"); congestion != "" {
+			proxy["congestion-controller"] = congestion
+		}
+		if relay := f.str("udp_relay_mode"); relay != "" {
+			proxy["udp-relay-mode"] = relay
+		}
+		setMihomoTLS(proxy, tls, "sni", true)
+	case "wireguard":
+		proxy["type"] = "wireguard"
+		for _, address := range f.strings("local_address") {
+			ip, _, _ := strings.Cut(address, "/")
+			if strings.Contains(ip, ":") {
+				proxy["ipv6"] = ip
+			} else {
+				proxy["ip"] = ip
+			}
+		}
+		proxy["private-key"] = f.str("private_key")
+		proxy["public-key"] = f.str("peer_public_key")
+		if key := f.str("pre_shared_key"); key != "" {
+			proxy["pre-shared-key"] = key
+		}
+		if reserved, reservedErr := wireGuardReserved(f["reserved"]); reservedErr != nil {
+			return nil, reservedErr
+		} else if reserved != nil {
+			proxy["reserved"] = reserved
+		}
+		if mtu := f.int("mtu"); mtu > 0 {
+			proxy["mtu"] = mtu
+		}
+	case "socks":
+		if version := f.str("version"); version != "" && version != "5" {
+			return nil, fmt.Errorf("socks version %s is not supported", version)
+		}
+		proxy["type"] = "socks5"
+		setMihomoCredentials(proxy, f)
+	case "http":
+		proxy["type"] = "http"
+		setMihomoCredentials(proxy, f)
+		if tls.bool("enabled") {
+			proxy["tls"] = true
+			setMihomoTLS(proxy, tls, "sni", true)
+		}
+	case "ssh":
+		proxy["type"] = "ssh"
+		proxy["username"] = f.str("user")
+		if password := f.str("password"); password != "" {
+			proxy["password"] = password
+		}
+		if key := f.str("private_key"); key != "" {
+			proxy["private-key"] = key
+		}
+	case "anytls":
+		proxy["type"] = "anytls"
+		proxy["password"] = f.str("password")
+		setMihomoTLS(proxy, tls, "sni", true)
+	default:
+		return nil, fmt.Errorf("outbound type %q has no mihomo proxy", outboundType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
+
+// setMihomoTLS copies a sing-box tls object. Protocols that always use TLS pass implicit.
+func setMihomoTLS(proxy map[string]any, tls fields, sniKey string, implicit bool) {
+	if !implicit {
+		if !tls.bool("enabled") {
+			return
+		}
+		proxy["tls"] = true
+	}
+	if sni := tls.str("server_name"); sni != "" {
+		proxy[sniKey] = sni
+	}
+	if tls.bool("insecure") {
+		proxy["skip-cert-verify"] = true
+	}
+	if alpn := tls.strings("alpn"); len(alpn) > 0 {
+		proxy["alpn"] = alpn
+	}
+	if utls := tls.sub("utls"); utls.bool("enabled") {
+		proxy["client-fingerprint"] = orDefault(utls.str("fingerprint"), "chrome")
+	}
+	if reality := tls.sub("reality"); reality.bool("enabled") {
+		proxy["reality-opts"] = map[string]any{"public-key": reality.str("public_key"), "short-id": reality.str("short_id")}
+	}
+}
+
+// setMihomoTransport copies a sing-box v2ray transport. The http transport is HTTP/2 over TLS.
+func setMihomoTransport(proxy map[string]any, transport fields, tls bool) error {
+	switch transportType := transport.str("type"); transportType {
+	case "":
+	case "ws":
+		proxy["network"] = "ws"
+		opts := map[string]any{"path": orDefault(transport.str("path"), "/")}
+		if host := transport.sub("headers").str("Host"); host != "" {
+			opts["headers"] = map[string]any{"Host": host}
+		}
+		if early := transport.int("max_early_data"); early > 0 {
+			opts["max-early-data"] = early
+			opts["early-data-header-name"] = transport.str("early_data_header_name")
+		}
+		proxy["ws-opts"] = opts
+	case "grpc":
+		proxy["network"] = "grpc"
+		proxy["grpc-opts"] = map[string]any{"grpc-service-name": transport.str("service_name")}
+	case "http":
+		hosts := transport.strings("host")
+		path := orDefault(transport.str("path"), "/")
+		if tls {
+			proxy["network"] = "h2"
+			proxy["h2-opts"] = map[string]any{"host": hosts, "path": path}
+			break
+		}
+		proxy["network"] = "http"
+		opts := map[string]any{"method": orDefault(transport.str("method"), "GET"), "path": []string{path}}
+		if len(hosts) > 0 {
+			opts["headers"] = map[string]any{"Host": hosts}
+		}
+		proxy["http-opts"] = opts
+	default:
+		return fmt.Errorf("transport %q is not supported by mihomo", transportType)
+	}
+	return nil
+}
+
+func setMihomoBandwidth(proxy map[string]any, f fields) {
+	if up := f.int("up_mbps"); up > 0 {
+		proxy["up"] = strconv.Itoa(up) + " Mbps"
+	}
+	if down := f.int("down_mbps"); down > 0 {
+		proxy["down"] = strconv.Itoa(down) + " Mbps"
+	}
+}
+
+func setMihomoCredentials(proxy map[string]any, f fields) {
+	if username := f.str("username"); username != "" {
+		proxy["username"] = username
+	}
+	if password := f.str("password"); password != "" {
+		proxy["password"] = password
+	}
+}
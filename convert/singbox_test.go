@@ -0,0 +1,237 @@
+package convert
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// decodeProxy decodes a proxy the way LoadProxies does, so nested maps are map[any]any.
+func decodeProxy(t *testing.T, source string) map[string]any {
+	t.Helper()
+	var proxy map[string]any
+	if err := yaml.Unmarshal([]byte(source), &proxy); err != nil {
+		t.Fatalf("decode proxy failed: %v", err)
+	}
+	return proxy
+}
+
+func TestToSingBoxOutbound(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxy    string
+		expected string
+	}{
+		{
+			name:     "shadowsocks with obfs",
+			proxy:    "{name: ss, type: ss, server: 1.2.3.4, port: 8388, cipher: aes-128-gcm, password: pass, plugin: obfs, plugin-opts: {mode: http, host: bing.com}}",
+			expected: `{"method":"aes-128-gcm","password":"pass","plugin":"obfs-local","plugin_opts":"obfs=http;obfs-host=bing.com","server":"1.2.3.4","server_port":8388,"tag":"ss","type":"shadowsocks"}`,
+		},
+		{
+			name:     "vmess over websocket tls",
+			proxy:    "{name: vm, type: vmess, server: a.com, port: 443, uuid: id, alterId: 0, cipher: auto, tls: true, servername: b.com, network: ws, ws-opts: {path: /ws, headers: {Host: b.com}}}",
+			expected: `{"alter_id":0,"security":"auto","server":"a.com","server_port":443,"tag":"vm","tls":{"enabled":true,"server_name":"b.com"},"transport":{"headers":{"Host":"b.com"},"path":"/ws","type":"ws"},"type":"vmess","uuid":"id"}`,
+		},
+		{
+			name:     "vless reality",
+			proxy:    "{name: vl, type: vless, server: a.com, port: 443, uuid: id, flow: xtls-rprx-vision, tls: true, servername: www.apple.com, client-fingerprint: chrome, reality-opts: {public-key: pk, short-id: ab}}",
+			expected: `{"flow":"xtls-rprx-vision","server":"a.com","server_port":443,"tag":"vl","tls":{"enabled":true,"reality":{"enabled":true,"public_key":"pk","short_id":"ab"},"server_name":"www.apple.com","utls":{"enabled":true,"fingerprint":"chrome"}},"type":"vless","uuid":"id"}`,
+		},
+		{
+			name:     "trojan grpc",
+			proxy:    "{name: tj, type: trojan, server: a.com, port: 443, password: pass, sni: b.com, skip-cert-verify: true, network: grpc, grpc-opts: {grpc-service-name: svc}}",
+			expected: `{"password":"pass","server":"a.com","server_port":443,"tag":"tj","tls":{"enabled":true,"insecure":true,"server_name":"b.com"},"transport":{"service_name":"svc","type":"grpc"},"type":"trojan"}`,
+		},
+		{
+			name:     "hysteria2 with obfs and port hopping",
+			proxy:    "{name: hy2, type: hysteria2, server: a.com, port: 443, ports: '443,20000-30000', password: pass, up: '50 Mbps', down: 100, obfs: salamander, obfs-password: secret, sni: b.com, alpn: [h3]}",
+			expected: `{"down_mbps":100,"obfs":{"password":"secret","type":"salamander"},"password":"pass","server":"a.com","server_port":443,"server_ports":["443:443","20000:30000"],"tag":"hy2","tls":{"alpn":["h3"],"enabled":true,"server_name":"b.com"},"type":"hysteria2","up_mbps":50}`,
+		},
+		{
+			name:     "tuic v5",
+			proxy:    "{name: tuic, type: tuic, server: a.com, port: 443, uuid: id, password: pass, congestion-controller: bbr, udp-relay-mode: native, alpn: [h3]}",
+			expected: `{"congestion_control":"bbr","password":"pass","server":"a.com","server_port":443,"tag":"tuic","tls":{"alpn":["h3"],"enabled":true},"type":"tuic","udp_relay_mode":"native","uuid":"id"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbound, err := ToSingBoxOutbound(decodeProxy(t, tt.proxy))
+			if err != nil {
+				t.Fatalf("convert failed: %v", err)
+			}
+			data, err := json.Marshal(outbound)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestToSingBoxOutboundUnsupported(t *testing.T) {
+	for _, proxy := range []string{
+		"{name: ssr, type: ssr, server: a.com, port: 443}",
+		"{name: ss, type: ss, server: a.com, port: 443, cipher: aes-128-gcm, password: p, plugin: shadow-tls}",
+		"{name: vm, type: vmess, server: a.com, port: 443, uuid: id, network: kcp}",
+		"{name: tuic4, type: tuic, server: a.com, port: 443, token: t}",
+		"{name: wg, type: wireguard, server: 1.2.3.4, port: 51820, ip: 172.16.0.2, private-key: priv, public-key: pub}",
+		"{type: trojan, server: a.com, port: 443}",
+	} {
+		if _, err := ToSingBoxOutbound(decodeProxy(t, proxy)); err == nil {
+			t.Errorf("expected %s to be rejected", proxy)
+		}
+	}
+}
+
+func TestToSingBoxEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		proxy    string
+		expected string
+	}{
+		{
+			name:     "wireguard",
+			proxy:    "{name: wg, type: wireguard, server: 1.2.3.4, port: 51820, ip: 172.16.0.2, ipv6: 'fd01::2', private-key: priv, public-key: pub, reserved: [1, 2, 3], mtu: 1280}",
+			expected: `{"address":["172.16.0.2/32","fd01::2/128"],"mtu":1280,"peers":[{"address":"1.2.3.4","allowed_ips":["0.0.0.0/0","::/0"],"port":51820,"public_key":"pub","reserved":[1,2,3]}],"private_key":"priv","tag":"wg","type":"wireguard"}`,
+		},
+		{
+			name:     "wireguard with allowed ips and keepalive",
+			proxy:    "{name: wg, type: wireguard, server: a.com, port: 51820, ip: 172.16.0.2/24, private-key: priv, public-key: pub, pre-shared-key: psk, allowed-ips: ['0.0.0.0/0'], persistent-keepalive: 25}",
+			expected: `{"address":["172.16.0.2/24"],"peers":[{"address":"a.com","allowed_ips":["0.0.0.0/0"],"persistent_keepalive_interval":25,"port":51820,"pre_shared_key":"psk","public_key":"pub"}],"private_key":"priv","tag":"wg","type":"wireguard"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, err := ToSingBoxEndpoint(decodeProxy(t, tt.proxy))
+			if err != nil {
+				t.Fatalf("convert failed: %v", err)
+			}
+			data, err := json.Marshal(endpoint)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Fatalf("expected\n%s\ngot\n%s", tt.expected, data)
+			}
+		})
+	}
+
+	for _, proxy := range []string{
+		"{name: wg, type: wireguard, server: 1.2.3.4, port: 51820, private-key: priv, public-key: pub}",
+		"{name: wg, type: wireguard, server: 1.2.3.4, port: 51820, ip: 172.16.0.2, private-key: priv, peers: [{server: 1.2.3.4, port: 51820, public-key: pub}]}",
+		"{name: tj, type: trojan, server: a.com, port: 443, password: p}",
+	} {
+		if _, err := ToSingBoxEndpoint(decodeProxy(t, proxy)); err == nil {
+			t.Errorf("expected %s to be rejected", proxy)
+		}
+	}
+}
+
+func TestSingBoxConfigGroupAndSkipped(t *testing.T) {
+	proxies := []map[string]any{
+		decodeProxy(t, "{name: a, type: trojan, server: a.com, port: 443, password: p}"),
+		decodeProxy(t, "{name: b, type: snell, server: b.com, port: 443, psk: p}"),
+		decodeProxy(t, "{name: c, type: ss, server: c.com, port: 8388, cipher: aes-128-gcm, password: p}"),
+	}
+	data, skipped, err := SingBoxConfig(proxies, SingBoxGroupURLTest)
+	if err != nil {
+		t.Fatalf("build config failed: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].Name != "b" || skipped[0].Err == nil {
+		t.Fatalf("expected the snell node to be reported, got %+v", skipped)
+	}
+
+	var config struct {
+		Outbounds []map[string]any `json:"outbounds"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("decode config failed: %v", err)
+	}
+	if len(config.Outbounds) != 3 {
+		t.Fatalf("expected a group and two nodes, got %d outbounds", len(config.Outbounds))
+	}
+	group := config.Outbounds[0]
+	if group["type"] != "urltest" || !reflect.DeepEqual(group["outbounds"], []any{"a", "c"}) {
+		t.Fatalf("unexpected group outbound %v", group)
+	}
+
+	data, _, err = SingBoxConfig(proxies[:1], SingBoxGroupSelector)
+	if err != nil {
+		t.Fatalf("build config failed: %v", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("decode config failed: %v", err)
+	}
+	if config.Outbounds[0]["type"] != "selector" || config.Outbounds[0]["default"] != "a" {
+		t.Fatalf("unexpected selector outbound %v", config.Outbounds[0])
+	}
+}
+
+func TestSingBoxConfigEndpoints(t *testing.T) {
+	proxies := []map[string]any{
+		decodeProxy(t, "{name: a, type: trojan, server: a.com, port: 443, password: p}"),
+		decodeProxy(t, "{name: wg, type: wireguard, server: 1.2.3.4, port: 51820, ip: 172.16.0.2, private-key: priv, public-key: pub}"),
+	}
+	data, skipped, err := SingBoxConfig(proxies, SingBoxGroupSelector)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("build config failed: %v, skipped %+v", err, skipped)
+	}
+	var config struct {
+		Outbounds []map[string]any `json:"outbounds"`
+		Endpoints []map[string]any `json:"endpoints"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("decode config failed: %v", err)
+	}
+	if len(config.Outbounds) != 2 || len(config.Endpoints) != 1 || config.Endpoints[0]["tag"] != "wg" {
+		t.Fatalf("expected the wireguard node as an endpoint, got %s", data)
+	}
+	if group := config.Outbounds[0]; !reflect.DeepEqual(group["outbounds"], []any{"a", "wg"}) {
+		t.Fatalf("expected the group to include the endpoint, got %v", group)
+	}
+}
+
+func TestSingBoxConfigWithoutNodesHasNoGroup(t *testing.T) {
+	proxies := []map[string]any{decodeProxy(t, "{name: b, type: snell, server: b.com, port: 443, psk: p}")}
+	for _, group := range []SingBoxGroup{SingBoxGroupURLTest, SingBoxGroupSelector} {
+		data, skipped, err := SingBoxConfig(proxies, group)
+		if err != nil {
+			t.Fatalf("build config failed: %v", err)
+		}
+		if len(skipped) != 1 {
+			t.Fatalf("expected the snell node to be reported, got %+v", skipped)
+		}
+		if !strings.Contains(string(data), `"outbounds": []`) {
+			t.Fatalf("expected no %s group without nodes, got %s", group, data)
+		}
+	}
+}
+
+func TestParseSingBoxGroup(t *testing.T) {
+	for value, expected := range map[string]SingBoxGroup{"": SingBoxGroupNone, "URLTest": SingBoxGroupURLTest, "selector": SingBoxGroupSelector} {
+		group, err := ParseSingBoxGroup(value)
+		if err != nil || group != expected {
+			t.Errorf("ParseSingBoxGroup(%q) = %q, %v; want %q", value, group, err, expected)
+		}
+	}
+	if _, err := ParseSingBoxGroup("fallback"); err == nil {
+		t.Errorf("expected an unsupported group to be rejected")
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	for value, expected := range map[string]ExportFormat{"": ExportClash, "mihomo": ExportClash, "sing-box": ExportSingBox, "SINGBOX": ExportSingBox, "uri": ExportURI, "Base64": ExportBase64} {
+		format, err := ParseExportFormat(value)
+		if err != nil || format != expected {
+			t.Errorf("ParseExportFormat(%q) = %q, %v; want %q", value, format, err, expected)
+		}
+	}
+	if _, err := ParseExportFormat("surge"); err == nil {
+		t.Errorf("expected an unsupported format to be rejected")
+	}
+}
@@ -0,0 +1,455 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// InputFormat is the detected format of a subscription body.
+type InputFormat string
+
+const (
+	InputClash   InputFormat = "clash"
+	InputURIList InputFormat = "uri"
+	InputBase64  InputFormat = "base64"
+	InputSingBox InputFormat = "singbox"
+)
+
+// LineError is a line of a URI list that could not be converted. Line counts from 1.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// OutboundError is a sing-box outbound that could not be converted. Index counts from 1 over
+// the outbounds list.
+type OutboundError struct {
+	Index int
+	Err   error
+}
+
+func (e *OutboundError) Error() string {
+	return fmt.Sprintf("outbound %d: %s", e.Index, e.Err)
+}
+
+func (e *OutboundError) Unwrap() error {
+	return e.Err
+}
+
+// shareSchemes are the URI schemes ParseShareLink understands.
+var shareSchemes = []string{"ss://", "vmess://", "vless://", "trojan://", "hysteria2://", "hy2://", "tuic://"}
+
+// otherSchemes are share URI schemes found in subscriptions that ParseShareLink cannot convert.
+// They still mark a body as a URI list, so such lines are reported and skipped.
+var otherSchemes = []string{"ssr://", "socks://", "socks5://", "hysteria://", "anytls://", "wireguard://", "wg://", "juicity://", "snell://"}
+
+// DetectFormat guesses the format of a subscription body. Anything that is not a sing-box
+// config, a URI list or a base64 encoded URI list is treated as Clash YAML.
+func DetectFormat(body []byte) InputFormat {
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var config struct {
+			Outbounds []json.RawMessage `json:"outbounds"`
+		}
+		if json.Unmarshal(trimmed, &config) == nil && config.Outbounds != nil {
+			return InputSingBox
+		}
+	}
+	if hasShareLink(string(trimmed)) {
+		return InputURIList
+	}
+	if decoded, ok := decodeBase64(string(trimmed)); ok && hasShareLink(decoded) {
+		return InputBase64
+	}
+	return InputClash
+}
+
+// hasShareLink reports whether any line of text, apart from blank and comment lines, starts
+// with a known share URI scheme.
+func hasShareLink(text string) bool {
+	for line := range strings.SplitSeq(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hasScheme(line, shareSchemes) || hasScheme(line, otherSchemes) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasScheme(line string, schemes []string) bool {
+	lower := strings.ToLower(line)
+	for _, scheme := range schemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProxies converts a URI list, base64 URI list or sing-box body into mihomo proxy maps.
+// Entries that cannot be converted are returned as LineErrors or OutboundErrors instead of
+// failing the body.
+func ParseProxies(body []byte, format InputFormat) ([]map[string]any, []error) {
+	switch format {
+	case InputSingBox:
+		return parseSingBoxOutbounds(body)
+	case InputBase64:
+		decoded, ok := decodeBase64(string(bytes.TrimSpace(body)))
+		if !ok {
+			return nil, []error{fmt.Errorf("invalid base64 subscription")}
+		}
+		return parseShareLinks(decoded)
+	case InputURIList:
+		return parseShareLinks(string(body))
+	default:
+		return nil, []error{fmt.Errorf("format %q has no proxy converter", format)}
+	}
+}
+
+func parseShareLinks(text string) ([]map[string]any, []error) {
+	var proxies []map[string]any
+	var errs []error
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxy, err := ParseShareLink(line)
+		if err != nil {
+			errs = append(errs, &LineError{Line: i + 1, Err: err})
+			continue
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, errs
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not, ignoring line breaks.
+func decodeBase64(text string) (string, bool) {
+	text = strings.Join(strings.Fields(text), "")
+	if text == "" {
+		return "", false
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(text); err == nil {
+			return string(decoded), true
+		}
+	}
+	return "", false
+}
+
+// ParseShareLink converts one share URI into a mihomo proxy map.
+func ParseShareLink(link string) (map[string]any, error) {
+	scheme, _, ok := strings.Cut(link, "://")
+	if !ok {
+		return nil, fmt.Errorf("not a share URI")
+	}
+	switch strings.ToLower(scheme) {
+	case "ss":
+		return parseShadowsocksLink(link)
+	case "vmess":
+		return parseVmessLink(link)
+	case "vless", "trojan", "hysteria2", "hy2", "tuic":
+		u, err := url.Parse(link)
+		if err != nil {
+			return nil, err
+		}
+		proxy, err := newLinkProxy(u)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		switch strings.ToLower(scheme) {
+		case "vless":
+			proxy["type"] = "vless"
+			proxy["uuid"] = u.User.Username()
+			if flow := query.Get("flow"); flow != "" {
+				proxy["flow"] = flow
+			}
+			if encoding := query.Get("packetEncoding"); encoding != "" {
+				proxy["packet-encoding"] = encoding
+			}
+			security := strings.ToLower(query.Get("security"))
+			if security == "tls" || security == "reality" {
+				proxy["tls"] = true
+				setLinkTLS(proxy, query, "servername")
+			}
+			if security == "reality" {
+				proxy["reality-opts"] = map[string]any{"public-key": query.Get("pbk"), "short-id": query.Get("sid")}
+			}
+			err = setLinkTransport(proxy, query)
+		case "trojan":
+			proxy["type"] = "trojan"
+			proxy["password"] = u.User.Username()
+			setLinkTLS(proxy, query, "sni")
+			err = setLinkTransport(proxy, query)
+		case "hysteria2", "hy2":
+			proxy["type"] = "hysteria2"
+			if password, ok := u.User.Password(); ok {
+				proxy["password"] = u.User.Username() + ":" + password
+			} else {
+				proxy["password"] = u.User.Username()
+			}
+			setLinkTLS(proxy, query, "sni")
+			if obfs := query.Get("obfs"); obfs != "" {
+				proxy["obfs"] = obfs
+				proxy["obfs-password"] = query.Get("obfs-password")
+			}
+			if ports := query.Get("mport"); ports != "" {
+				proxy["ports"] = ports
+			}
+		case "tuic":
+			proxy["type"] = "tuic"
+			proxy["uuid"] = u.User.Username()
+			proxy["password"], _ = u.User.Password()
+			if congestion := query.Get("congestion_control"); congestion != "" {
+				proxy["congestion-controller"] = congestion
+			}
+			if relay := query.Get("udp_relay_mode"); relay != "" {
+				proxy["udp-relay-mode"] = relay
+			}
+			setLinkTLS(proxy, query, "sni")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return proxy, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", scheme)
+	}
+}
+
+// newLinkProxy starts a proxy map with the name, server and port of a share URI.
+func newLinkProxy(u *url.URL) (map[string]any, error) {
+	server := u.Hostname()
+	if server == "" {
+		return nil, fmt.Errorf("missing server")
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %q", u.Port())
+	}
+	if u.User == nil {
+		return nil, fmt.Errorf("missing credentials")
+	}
+	name := strings.TrimSpace(u.Fragment)
+	if name == "" {
+		name = net.JoinHostPort(server, u.Port())
+	}
+	return map[string]any{"name": name, "server": server, "port": port, "udp": true}, nil
+}
+
+// setLinkTLS copies the TLS parameters shared by the URI schemes.
+func setLinkTLS(proxy map[string]any, query url.Values, sniKey string) {
+	if sni := query.Get("sni"); sni != "" {
+		proxy[sniKey] = sni
+	} else if peer := query.Get("peer"); peer != "" {
+		proxy[sniKey] = peer
+	}
+	for _, key := range []string{"allowInsecure", "insecure", "allow_insecure"} {
+		if value := query.Get(key); value == "1" || strings.EqualFold(value, "true") {
+			proxy["skip-cert-verify"] = true
+		}
+	}
+	if alpn := query.Get("alpn"); alpn != "" {
+		proxy["alpn"] = strings.Split(alpn, ",")
+	}
+	if fingerprint := query.Get("fp"); fingerprint != "" {
+		proxy["client-fingerprint"] = fingerprint
+	}
+}
+
+// setLinkTransport copies the v2ray transport of vless and trojan URIs.
+func setLinkTransport(proxy map[string]any, query url.Values) error {
+	network := strings.ToLower(query.Get("type"))
+	host, path := query.Get("host"), query.Get("path")
+	switch network {
+	case "", "tcp":
+		if strings.EqualFold(query.Get("headerType"), "http") {
+			proxy["network"] = "http"
+			opts := map[string]any{"path": []string{orDefault(path, "/")}}
+			if host != "" {
+				opts["headers"] = map[string]any{"Host": strings.Split(host, ",")}
+			}
+			proxy["http-opts"] = opts
+		}
+	case "ws":
+		proxy["network"] = "ws"
+		opts := map[string]any{"path": orDefault(path, "/")}
+		if host != "" {
+			opts["headers"] = map[string]any{"Host": host}
+		}
+		proxy["ws-opts"] = opts
+	case "grpc":
+		proxy["network"] = "grpc"
+		proxy["grpc-opts"] = map[string]any{"grpc-service-name": query.Get("serviceName")}
+	case "h2", "http":
+		proxy["network"] = "h2"
+		opts := map[string]any{"path": orDefault(path, "/")}
+		if host != "" {
+			opts["host"] = strings.Split(host, ",")
+		}
+		proxy["h2-opts"] = opts
+	default:
+		return fmt.Errorf("unsupported transport %q", network)
+	}
+	return nil
+}
+
+// parseShadowsocksLink accepts SIP002 URIs and the legacy ss://base64(method:password@host:port) form.
+func parseShadowsocksLink(link string) (map[string]any, error) {
+	rest := link[len("ss://"):]
+	rest, fragment, _ := strings.Cut(rest, "#")
+	if !strings.Contains(rest, "@") {
+		encoded, query, _ := strings.Cut(rest, "?")
+		decoded, ok := decodeBase64(strings.TrimSuffix(encoded, "/"))
+		if !ok {
+			return nil, fmt.Errorf("invalid legacy shadowsocks URI")
+		}
+		method, hostPort, ok := strings.Cut(decoded, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid legacy shadowsocks URI")
+		}
+		password, hostPort, ok := cutLast(hostPort, "@")
+		if !ok {
+			return nil, fmt.Errorf("invalid legacy shadowsocks URI")
+		}
+		rest = url.UserPassword(method, password).String() + "@" + hostPort
+		if query != "" {
+			rest += "?" + query
+		}
+	}
+	u, err := url.Parse("ss://" + rest + "#" + fragment)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := newLinkProxy(u)
+	if err != nil {
+		return nil, err
+	}
+	method := u.User.Username()
+	password, hasPassword := u.User.Password()
+	if !hasPassword {
+		decoded, ok := decodeBase64(method)
+		if !ok {
+			return nil, fmt.Errorf("invalid shadowsocks user info")
+		}
+		method, password, ok = strings.Cut(decoded, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid shadowsocks user info")
+		}
+	}
+	proxy["type"] = "ss"
+	proxy["cipher"] = method
+	proxy["password"] = password
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		name, opts, err := parsePluginOpts(plugin)
+		if err != nil {
+			return nil, err
+		}
+		proxy["plugin"] = name
+		proxy["plugin-opts"] = opts
+	}
+	return proxy, nil
+}
+
+func cutLast(value, separator string) (string, string, bool) {
+	index := strings.LastIndex(value, separator)
+	if index < 0 {
+		return value, "", false
+	}
+	return value[:index], value[index+len(separator):], true
+}
+
+// parsePluginOpts converts a SIP003 plugin string such as "obfs-local;obfs=http;obfs-host=a.com"
+// into the mihomo plugin name and plugin-opts.
+func parsePluginOpts(plugin string) (string, map[string]any, error) {
+	parts := strings.Split(plugin, ";")
+	values := make(map[string]string)
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	_, tls := values["tls"]
+	switch strings.TrimSpace(parts[0]) {
+	case "obfs-local", "simple-obfs", "obfs":
+		return "obfs", map[string]any{"mode": values["obfs"], "host": values["obfs-host"]}, nil
+	case "v2ray-plugin":
+		opts := map[string]any{"mode": orDefault(values["mode"], "websocket"), "host": values["host"], "path": values["path"]}
+		if tls {
+			opts["tls"] = true
+		}
+		return "v2ray-plugin", opts, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported shadowsocks plugin %q", parts[0])
+	}
+}
+
+// parseVmessLink converts the v2rayN vmess:// form, a base64 encoded JSON object.
+func parseVmessLink(link string) (map[string]any, error) {
+	decoded, ok := decodeBase64(link[len("vmess://"):])
+	if !ok {
+		return nil, fmt.Errorf("invalid vmess URI")
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(decoded), &raw); err != nil {
+		return nil, fmt.Errorf("invalid vmess JSON: %w", err)
+	}
+	config := newFields(raw)
+	server := config.str("add")
+	port := config.int("port")
+	if server == "" || port <= 0 {
+		return nil, fmt.Errorf("missing server or port")
+	}
+	proxy := map[string]any{
+		"name":    orDefault(config.str("ps"), net.JoinHostPort(server, strconv.Itoa(port))),
+		"type":    "vmess",
+		"server":  server,
+		"port":    port,
+		"uuid":    config.str("id"),
+		"alterId": config.int("aid"),
+		"cipher":  orDefault(config.str("scy"), "auto"),
+		"udp":     true,
+	}
+	if strings.EqualFold(config.str("tls"), "tls") {
+		proxy["tls"] = true
+		if sni := config.str("sni"); sni != "" {
+			proxy["servername"] = sni
+		}
+		if alpn := config.str("alpn"); alpn != "" {
+			proxy["alpn"] = strings.Split(alpn, ",")
+		}
+		if fingerprint := config.str("fp"); fingerprint != "" {
+			proxy["client-fingerprint"] = fingerprint
+		}
+	}
+	query := url.Values{}
+	query.Set("type", config.str("net"))
+	query.Set("host", config.str("host"))
+	query.Set("path", config.str("path"))
+	query.Set("serviceName", config.str("path"))
+	if strings.EqualFold(config.str("type"), "http") {
+		query.Set("headerType", "http")
+	}
+	if err := setLinkTransport(proxy, query); err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
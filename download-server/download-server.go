@@ -1,15 +1,27 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
+	"log"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/faceair/clash-speedtest/speedtester"
 )
 
+var payloadFlag = flag.String("payload", "zero", "default /__down payload: zero or random (random defeats compressing proxies); requests may override it with ?payload=")
+
 func main() {
+	flag.Parse()
+	defaultPayload, err := speedtester.ParsePayloadType(*payloadFlag)
+	if err != nil {
+		log.Fatalf("parse payload failed: %s", err)
+	}
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.WriteHeader(http.StatusOK)
@@ -29,14 +41,36 @@ func main() {
 			return
 		}
 
+		payloadType := defaultPayload
+		if payloadValue := r.URL.Query().Get("payload"); payloadValue != "" {
+			payloadType, err = speedtester.ParsePayloadType(payloadValue)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+		}
+
+		var reader io.Reader = speedtester.NewPayloadReader(payloadType, rand.Uint64(), byteSize)
+		if seedValue := r.URL.Query().Get("seed"); seedValue != "" {
+			seed, err := strconv.ParseUint(seedValue, 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			reader = speedtester.NewSeededReader(seed, byteSize)
+			w.Header().Set(speedtester.PayloadSeedHeader, seedValue)
+		}
+
 		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=speedtest-%d.bin", byteSize))
 		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set(speedtester.HeaderEchoHeader, speedtester.EncodeHeaderEcho(r.Header))
 		w.WriteHeader(http.StatusOK)
 		if r.Method == http.MethodHead {
 			return
 		}
 
-		reader := speedtester.NewZeroReader(byteSize)
 		io.Copy(w, reader)
 	})
 
@@ -51,5 +85,39 @@ func main() {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// /__ip returns the caller's address, used by the tester to find each proxy's exit IP.
+	http.HandleFunc("/__ip", func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(host))
+	})
+
+	go serveUDPEcho(":8080")
+
 	http.ListenAndServe(":8080", nil)
 }
+
+// serveUDPEcho sends every datagram back to its sender, used by the UDP relay test.
+func serveUDPEcho(addr string) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		log.Printf("listen udp echo on %s failed: %s", addr, err)
+		return
+	}
+	defer conn.Close()
+
+	buffer := make([]byte, 64*1024)
+	for {
+		n, remote, err := conn.ReadFrom(buffer)
+		if err != nil {
+			log.Printf("read udp echo failed: %s", err)
+			return
+		}
+		conn.WriteTo(buffer[:n], remote)
+	}
+}
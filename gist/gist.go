@@ -94,11 +94,16 @@ func ParseGistID(address string) (string, error) {
 }
 
 func (u *Uploader) UpdateFile(token, address, filename string, content []byte) error {
+	return u.UpdateFiles(token, address, map[string][]byte{filename: content})
+}
+
+// UpdateFiles updates several files of a gist in one request, e.g. the output YAML and its report.
+func (u *Uploader) UpdateFiles(token, address string, files map[string][]byte) error {
 	if token == "" {
 		return fmt.Errorf("gist token is empty")
 	}
-	if filename == "" {
-		return fmt.Errorf("gist filename is empty")
+	if len(files) == 0 {
+		return fmt.Errorf("gist files are empty")
 	}
 
 	gistID, err := ParseGistID(address)
@@ -107,9 +112,13 @@ func (u *Uploader) UpdateFile(token, address, filename string, content []byte) e
 	}
 
 	payload := updateRequest{
-		Files: map[string]gistFile{
-			filename: {Content: string(content)},
-		},
+		Files: make(map[string]gistFile, len(files)),
+	}
+	for filename, content := range files {
+		if filename == "" {
+			return fmt.Errorf("gist filename is empty")
+		}
+		payload.Files[filename] = gistFile{Content: string(content)}
 	}
 	body, err := json.Marshal(payload)
 	if err != nil {
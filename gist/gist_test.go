@@ -149,3 +149,29 @@ func TestProxyFromEnvironmentForHTTPS(t *testing.T) {
 		t.Fatalf("expected HTTPS_PROXY to be used, got: %v", proxy)
 	}
 }
+
+func TestUpdateFiles(t *testing.T) {
+	payloads := make(chan updateRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		var payload updateRequest
+		if err := json.NewDecoder(request.Body).Decode(&payload); err != nil {
+			t.Errorf("unmarshal payload failed: %v", err)
+		}
+		payloads <- payload
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	uploader := NewUploaderWithBase(server.Client(), server.URL)
+	files := map[string][]byte{"fastsub.yaml": []byte("yaml"), "report.html": []byte("<html>")}
+	if err := uploader.UpdateFiles("test-token", "abc123", files); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload := <-payloads
+	if len(payload.Files) != 2 || payload.Files["fastsub.yaml"].Content != "yaml" || payload.Files["report.html"].Content != "<html>" {
+		t.Fatalf("expected both files in one request, got %+v", payload.Files)
+	}
+	if err := uploader.UpdateFiles("test-token", "abc123", nil); err == nil {
+		t.Fatalf("expected an error without files")
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/charmbracelet/bubbletea v1.1.0
 	github.com/charmbracelet/lipgloss v0.13.0
 	github.com/metacubex/mihomo v1.19.19
+	github.com/oschwald/maxminddb-golang v1.12.0
 	golang.org/x/term v0.39.0
 	gopkg.in/yaml.v2 v2.4.0
 )
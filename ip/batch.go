@@ -0,0 +1,113 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+)
+
+// resolveConcurrency bounds the parallel DNS lookups of LocateAll.
+const resolveConcurrency = 16
+
+// ResolveHost returns host as an IP address, resolving it first when it is a hostname.
+func ResolveHost(host string) (string, error) {
+	addr, err := resolveAddr(host)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// resolveAddr parses ip, resolving it first when it is a hostname such as a proxy server name.
+func resolveAddr(ip string) (netip.Addr, error) {
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		return addr.Unmap(), nil
+	}
+	addrs, err := net.LookupIP(ip)
+	if err != nil || len(addrs) == 0 {
+		return netip.Addr{}, fmt.Errorf("resolve %s failed: %v", ip, err)
+	}
+	addr, _ := netip.AddrFromSlice(addrs[0])
+	return addr.Unmap(), nil
+}
+
+// LocateAll locates many hosts at once. Hostnames are resolved in parallel first, so hosts
+// sharing an address and cached addresses need no lookup, and the distinct addresses go to
+// the locator in a single batch when it supports one. The result is keyed by the given host;
+// hosts that could not be located are missing from it and reported in the error.
+func LocateAll(locator Locator, hosts []string) (map[string]*IPLocation, error) {
+	unique := make([]string, 0, len(hosts))
+	seenHosts := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		if _, ok := seenHosts[host]; ok || host == "" {
+			continue
+		}
+		seenHosts[host] = struct{}{}
+		unique = append(unique, host)
+	}
+
+	// Each worker writes only its own slot, so no lock is needed while they run.
+	addrs := make([]string, len(unique))
+	resolveErrs := make([]error, len(unique))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, resolveConcurrency)
+	for i, host := range unique {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			addrs[i], resolveErrs[i] = ResolveHost(host)
+		}()
+	}
+	wg.Wait()
+
+	resolved := make(map[string]string, len(unique))
+	ips := make([]string, 0, len(unique))
+	seen := make(map[string]struct{}, len(unique))
+	var errs []error
+	for i, host := range unique {
+		if resolveErrs[i] != nil {
+			errs = append(errs, resolveErrs[i])
+			continue
+		}
+		resolved[host] = addrs[i]
+		if _, ok := seen[addrs[i]]; ok {
+			continue
+		}
+		seen[addrs[i]] = struct{}{}
+		ips = append(ips, addrs[i])
+	}
+	found, err := locateEach(locator, ips)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	locations := make(map[string]*IPLocation, len(hosts))
+	for host, ip := range resolved {
+		if location, ok := found[ip]; ok {
+			locations[host] = location
+		}
+	}
+	return locations, errors.Join(errs...)
+}
+
+// locateEach uses the batch lookup when available and falls back to one lookup per address.
+func locateEach(locator Locator, ips []string) (map[string]*IPLocation, error) {
+	if batch, ok := locator.(BatchLocator); ok {
+		return batch.LocateBatch(ips)
+	}
+	locations := make(map[string]*IPLocation, len(ips))
+	var errs []error
+	for _, ip := range ips {
+		location, err := locator.Locate(ip)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		locations[ip] = location
+	}
+	return locations, errors.Join(errs...)
+}
@@ -0,0 +1,142 @@
+package ip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	Location  *IPLocation `json:"location"`
+	FetchedAt time.Time   `json:"fetched_at"`
+}
+
+// CachedLocator keeps the locations found by another locator in a JSON file keyed by IP,
+// so repeated runs only look up addresses that are new or older than the TTL.
+type CachedLocator struct {
+	locator Locator
+	path    string
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// NewCachedLocator loads the cache at path. A missing or unreadable cache file starts empty.
+func NewCachedLocator(locator Locator, path string, ttl time.Duration) *CachedLocator {
+	cache := &CachedLocator{
+		locator: locator,
+		path:    path,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache.entries)
+	}
+	return cache
+}
+
+func (c *CachedLocator) lookup(ip string) (*IPLocation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[ip]
+	if !ok || entry.Location == nil || c.now().Sub(entry.FetchedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.Location, true
+}
+
+func (c *CachedLocator) store(ip string, location *IPLocation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ip] = cacheEntry{Location: location, FetchedAt: c.now()}
+	c.dirty = true
+}
+
+func (c *CachedLocator) Locate(host string) (*IPLocation, error) {
+	ip, err := ResolveHost(host)
+	if err != nil {
+		return nil, err
+	}
+	if location, ok := c.lookup(ip); ok {
+		return location, nil
+	}
+	location, err := c.locator.Locate(ip)
+	if err != nil {
+		return nil, err
+	}
+	c.store(ip, location)
+	return location, nil
+}
+
+// LocateBatch answers from the cache and looks up only the misses, in one batch when the
+// wrapped locator supports it.
+func (c *CachedLocator) LocateBatch(ips []string) (map[string]*IPLocation, error) {
+	locations := make(map[string]*IPLocation, len(ips))
+	var misses []string
+	for _, ip := range ips {
+		if location, ok := c.lookup(ip); ok {
+			locations[ip] = location
+			continue
+		}
+		misses = append(misses, ip)
+	}
+	if len(misses) == 0 {
+		return locations, nil
+	}
+
+	found, err := locateEach(c.locator, misses)
+	for ip, location := range found {
+		c.store(ip, location)
+		locations[ip] = location
+	}
+	return locations, err
+}
+
+// Save writes the cache back to disk if any entry changed.
+func (c *CachedLocator) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	now := c.now()
+	for ip, entry := range c.entries {
+		if now.Sub(entry.FetchedAt) > c.ttl {
+			delete(c.entries, ip)
+		}
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("create geoip cache directory failed: %w", err)
+	}
+	// Write to a temporary file first so an interrupted save never leaves a truncated cache.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write geoip cache failed: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return errors.Join(fmt.Errorf("replace geoip cache failed: %w", err), os.Remove(tmp))
+	}
+	c.dirty = false
+	return nil
+}
+
+// DefaultCachePath is the GeoIP cache location under the user cache directory.
+func DefaultCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "clash-speedtest", "geoip.json")
+}
@@ -0,0 +1,114 @@
+package ip
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingLocator answers from a fixed table and records every lookup it receives.
+type countingLocator struct {
+	locations map[string]*IPLocation
+	lookups   []string
+}
+
+func (l *countingLocator) Locate(ip string) (*IPLocation, error) {
+	l.lookups = append(l.lookups, ip)
+	location, ok := l.locations[ip]
+	if !ok {
+		return nil, errors.New("unknown address " + ip)
+	}
+	return location, nil
+}
+
+func TestCachedLocatorPersistsAndExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.json")
+	inner := &countingLocator{locations: map[string]*IPLocation{
+		"203.0.113.7": {CountryCode: "US"},
+	}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewCachedLocator(inner, path, time.Hour)
+	cache.now = func() time.Time { return now }
+	if _, err := cache.Locate("203.0.113.7"); err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if _, err := cache.Locate("203.0.113.7"); err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if len(inner.lookups) != 1 {
+		t.Fatalf("expected the second lookup to hit the cache, got %v", inner.lookups)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("save cache failed: %v", err)
+	}
+
+	reloaded := NewCachedLocator(inner, path, time.Hour)
+	reloaded.now = func() time.Time { return now.Add(30 * time.Minute) }
+	location, err := reloaded.Locate("203.0.113.7")
+	if err != nil || location.CountryCode != "US" {
+		t.Fatalf("expected a cache hit after reload, got %+v (%v)", location, err)
+	}
+	if len(inner.lookups) != 1 {
+		t.Fatalf("expected the reloaded cache to answer, got %v", inner.lookups)
+	}
+
+	reloaded.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := reloaded.Locate("203.0.113.7"); err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if len(inner.lookups) != 2 {
+		t.Fatalf("expected an expired entry to be looked up again, got %v", inner.lookups)
+	}
+}
+
+func TestCachedLocatorIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.json")
+	if err := writeFile(path, "{not json"); err != nil {
+		t.Fatal(err)
+	}
+	inner := &countingLocator{locations: map[string]*IPLocation{"203.0.113.7": {CountryCode: "US"}}}
+	cache := NewCachedLocator(inner, path, time.Hour)
+	if _, err := cache.Locate("203.0.113.7"); err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("expected save to replace the corrupt cache, got %v", err)
+	}
+}
+
+func TestLocateAllResolvesDeduplicatesAndUsesCache(t *testing.T) {
+	inner := &countingLocator{locations: map[string]*IPLocation{
+		"198.51.100.20": {CountryCode: "JP"},
+		"203.0.113.7":   {CountryCode: "US"},
+	}}
+	cache := NewCachedLocator(inner, filepath.Join(t.TempDir(), "geoip.json"), time.Hour)
+
+	// The IPv4-mapped form resolves to the same address as the plain one.
+	hosts := []string{"::ffff:198.51.100.20", "198.51.100.20", "203.0.113.7", "203.0.113.7", "192.0.2.1"}
+	locations, err := LocateAll(cache, hosts)
+	if err == nil {
+		t.Fatalf("expected the unknown address to be reported")
+	}
+	for _, host := range []string{"::ffff:198.51.100.20", "198.51.100.20", "203.0.113.7"} {
+		if locations[host] == nil {
+			t.Fatalf("expected %s to be located, got %v", host, locations)
+		}
+	}
+	if len(inner.lookups) != 3 {
+		t.Fatalf("expected one lookup per distinct address, got %v", inner.lookups)
+	}
+
+	if _, err := LocateAll(cache, []string{"198.51.100.20", "203.0.113.7"}); err != nil {
+		t.Fatalf("locate all failed: %v", err)
+	}
+	if len(inner.lookups) != 3 {
+		t.Fatalf("expected cached addresses not to be looked up again, got %v", inner.lookups)
+	}
+}
+
+func writeFile(path, content string) error {
+	return os.WriteFile(path, []byte(content), 0o644)
+}
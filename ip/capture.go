@@ -0,0 +1,49 @@
+package ip
+
+import (
+	"errors"
+	"regexp"
+)
+
+// NameCapture extracts named capture groups from original node names, so tags such as
+// "IPLC" or "x0.5" survive renaming as {{.Capture.<group>}} in the rename template.
+type NameCapture struct {
+	re       *regexp.Regexp
+	fallback string
+}
+
+// NewNameCapture compiles pattern, which must contain at least one named group such as
+// (?P<multiplier>x[\d.]+). Groups of names that do not match are set to fallback.
+func NewNameCapture(pattern, fallback string) (*NameCapture, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return &NameCapture{re: re, fallback: fallback}, nil
+		}
+	}
+	return nil, errors.New("capture pattern has no named group, use (?P<name>...)")
+}
+
+// Extract returns every named group of the pattern. A group gets the fallback value when
+// the name does not match or the group took no part in the match.
+func (c *NameCapture) Extract(name string) map[string]string {
+	if c == nil {
+		return map[string]string{}
+	}
+	match := c.re.FindStringSubmatchIndex(name)
+	groups := make(map[string]string)
+	for i, group := range c.re.SubexpNames() {
+		if group == "" {
+			continue
+		}
+		if match == nil || match[2*i] < 0 {
+			groups[group] = c.fallback
+			continue
+		}
+		groups[group] = name[match[2*i]:match[2*i+1]]
+	}
+	return groups
+}
@@ -0,0 +1,65 @@
+package ip
+
+import "testing"
+
+func TestNameCaptureExtract(t *testing.T) {
+	capture, err := NewNameCapture(`(?P<line>IPLC|IEPL)?.*?(?P<multiplier>x[\d.]+)`, "none")
+	if err != nil {
+		t.Fatalf("compile capture failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected map[string]string
+	}{
+		{name: "IPLC 香港 01 x0.5", expected: map[string]string{"line": "IPLC", "multiplier": "x0.5"}},
+		{name: "香港 02 x2", expected: map[string]string{"line": "none", "multiplier": "x2"}},
+		{name: "香港 03", expected: map[string]string{"line": "none", "multiplier": "none"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := capture.Extract(tt.name)
+			if len(groups) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, groups)
+			}
+			for key, value := range tt.expected {
+				if groups[key] != value {
+					t.Fatalf("expected %s=%q, got %q", key, value, groups[key])
+				}
+			}
+		})
+	}
+}
+
+func TestNewNameCaptureRejectsPatterns(t *testing.T) {
+	if _, err := NewNameCapture(`(IPLC)`, ""); err == nil {
+		t.Fatalf("expected a pattern without named groups to be rejected")
+	}
+	if _, err := NewNameCapture(`(?P<tag>`, ""); err == nil {
+		t.Fatalf("expected an invalid pattern to be rejected")
+	}
+}
+
+func TestGenerateNodeNameFromNodeCapture(t *testing.T) {
+	capture, err := NewNameCapture(`(?P<multiplier>x[\d.]+)|(?P<tag>Netflix)`, "-")
+	if err != nil {
+		t.Fatalf("compile capture failed: %v", err)
+	}
+	node := NodeInfo{
+		Location: &IPLocation{CountryCode: "HK"},
+		Capture:  capture.Extract("HK IPLC 01 x0.5"),
+	}
+	name, err := GenerateNodeNameFromNode("{{.CountryCode}} {{.Index}} {{.Capture.multiplier}} {{.Capture.tag}}", node, make(map[string]int))
+	if err != nil {
+		t.Fatalf("template error: %v", err)
+	}
+	if name != "HK 001 x0.5 -" {
+		t.Fatalf("expected captured values in name, got %q", name)
+	}
+
+	// Without a capture pattern the map is empty rather than nil, so templates still render.
+	name, err = GenerateNodeNameFromNode("{{.CountryCode}}{{.Capture.multiplier}}", NodeInfo{Location: &IPLocation{CountryCode: "US"}}, make(map[string]int))
+	if err != nil || name != "US" {
+		t.Fatalf("expected an empty capture, got %q (%v)", name, err)
+	}
+}
@@ -0,0 +1,86 @@
+package ip
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// IndexStrategy decides how the {{.Index}} of a renamed node is numbered.
+type IndexStrategy string
+
+const (
+	// IndexSequential numbers nodes per country in output order.
+	IndexSequential IndexStrategy = "sequential"
+	// IndexHash derives the index from a hash of server:port, so a node keeps its name across runs.
+	IndexHash IndexStrategy = "hash"
+	// IndexCountryType numbers nodes per country and protocol type in output order.
+	IndexCountryType IndexStrategy = "country-type"
+)
+
+// hashIndexDigits is the number of hex digits of a hash index. Six digits keep collisions rare
+// for subscriptions of a few thousand nodes; UniqueNames still suffixes the ones that happen.
+const hashIndexDigits = 6
+
+func ParseIndexStrategy(value string) (IndexStrategy, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch IndexStrategy(normalized) {
+	case "", IndexSequential:
+		return IndexSequential, nil
+	case IndexHash:
+		return IndexHash, nil
+	case IndexCountryType:
+		return IndexCountryType, nil
+	default:
+		return "", fmt.Errorf("unsupported rename index strategy %q", value)
+	}
+}
+
+// index returns the formatted index of node in countryCode. Counting strategies record the node
+// in nameCount and return a three digit number; IndexHash returns hashIndexDigits hex digits.
+func (s IndexStrategy) index(node NodeInfo, countryCode string, nameCount map[string]int) string {
+	switch s {
+	case IndexHash:
+		key := node.Address
+		if key == "" {
+			key = node.OriginalName
+		}
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		return fmt.Sprintf("%0*x", hashIndexDigits, hash.Sum32()>>(32-4*hashIndexDigits))
+	case IndexCountryType:
+		key := countryCode + "/" + strings.ToLower(node.Type)
+		nameCount[key]++
+		return fmt.Sprintf("%03d", nameCount[key])
+	default:
+		nameCount[countryCode]++
+		return fmt.Sprintf("%03d", nameCount[countryCode])
+	}
+}
+
+// UniqueNames returns names with duplicates suffixed by " #2", " #3", ... in order of appearance.
+// A suffixed name never takes a name that appears elsewhere in the list, since mihomo rejects
+// configs with duplicate proxy names.
+func UniqueNames(names []string) []string {
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, len(names))
+	for i, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique[i] = name
+			continue
+		}
+		candidate := name
+		for n := 2; taken[candidate]; n++ {
+			candidate = fmt.Sprintf("%s #%d", name, n)
+		}
+		taken[candidate] = true
+		seen[candidate] = true
+		unique[i] = candidate
+	}
+	return unique
+}
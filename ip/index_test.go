@@ -0,0 +1,92 @@
+package ip
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseIndexStrategy(t *testing.T) {
+	tests := map[string]IndexStrategy{
+		"":             IndexSequential,
+		"Sequential":   IndexSequential,
+		" hash ":       IndexHash,
+		"country-type": IndexCountryType,
+	}
+	for value, expected := range tests {
+		strategy, err := ParseIndexStrategy(value)
+		if err != nil || strategy != expected {
+			t.Fatalf("ParseIndexStrategy(%q) = %q, %v; expected %q", value, strategy, err, expected)
+		}
+	}
+	if _, err := ParseIndexStrategy("random"); err == nil {
+		t.Fatalf("expected an unknown strategy to be rejected")
+	}
+}
+
+func TestRenamerHashIndexIsStable(t *testing.T) {
+	tmpl := "{{.CountryCode}} {{.Index}}"
+	nodes := []NodeInfo{
+		{Location: &IPLocation{CountryCode: "US"}, Address: "us1.example.com:443"},
+		{Location: &IPLocation{CountryCode: "US"}, Address: "us2.example.com:443"},
+	}
+
+	first, _ := NewRenamer(tmpl, IndexHash)
+	second, _ := NewRenamer(tmpl, IndexHash)
+	a := first.Name(nodes[0])
+	b := first.Name(nodes[1])
+	// Renaming in reverse order must not change the names.
+	if second.Name(nodes[1]) != b || second.Name(nodes[0]) != a {
+		t.Fatalf("expected hash indexes to ignore order, got %q and %q", a, b)
+	}
+	if a == b {
+		t.Fatalf("expected different servers to get different indexes, both got %q", a)
+	}
+}
+
+func TestRenamerHashIndexWidth(t *testing.T) {
+	renamer, _ := NewRenamer("{{.Index}}", IndexHash)
+	seen := make(map[string]string)
+	for i := range 2000 {
+		address := fmt.Sprintf("node%d.example.com:443", i)
+		index := renamer.Name(NodeInfo{Location: &IPLocation{CountryCode: "US"}, Address: address})
+		if len(index) != hashIndexDigits {
+			t.Fatalf("expected %d hex digits, got %q", hashIndexDigits, index)
+		}
+		if other, ok := seen[index]; ok {
+			t.Fatalf("expected distinct indexes, %s and %s both got %q", other, address, index)
+		}
+		seen[index] = address
+	}
+}
+
+func TestRenamerCountryTypeIndex(t *testing.T) {
+	renamer, err := NewRenamer("{{.CountryCode}} {{.Type}} {{.Index}}", IndexCountryType)
+	if err != nil {
+		t.Fatalf("create renamer failed: %v", err)
+	}
+	hk := &IPLocation{CountryCode: "HK"}
+	names := []string{
+		renamer.Name(NodeInfo{Location: hk, Type: "Trojan"}),
+		renamer.Name(NodeInfo{Location: hk, Type: "Vmess"}),
+		renamer.Name(NodeInfo{Location: hk, Type: "Trojan"}),
+	}
+	expected := []string{"HK Trojan 001", "HK Vmess 001", "HK Trojan 002"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestNewRenamerRejectsInvalidTemplate(t *testing.T) {
+	if _, err := NewRenamer("{{.CountryCode", IndexSequential); err == nil {
+		t.Fatalf("expected an invalid template to be rejected")
+	}
+}
+
+func TestUniqueNames(t *testing.T) {
+	names := UniqueNames([]string{"HK 001", "old node", "HK 001", "old node", "HK 001 #2", "HK 001"})
+	expected := []string{"HK 001", "old node", "HK 001 #3", "old node #2", "HK 001 #2", "HK 001 #4"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+}
@@ -1,38 +1,182 @@
 package ip
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type IPLocation struct {
 	Country     string `json:"country"`
 	CountryCode string `json:"countryCode"`
+	City        string `json:"city"`
+	ASN         uint   `json:"asn"`
+	ISP         string `json:"isp"`
 }
 
-func GetIPLocation(ip string) (*IPLocation, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(fmt.Sprintf("http://ip-api.com/json/%s?fields=country,countryCode", ip))
-	if err != nil {
-		return nil, err
+// Locator resolves the geolocation of an IP address or hostname.
+type Locator interface {
+	Locate(ip string) (*IPLocation, error)
+}
+
+// BatchLocator is implemented by locators that can resolve many addresses in one round trip.
+// Addresses that cannot be located are left out of the result.
+type BatchLocator interface {
+	Locator
+	LocateBatch(ips []string) (map[string]*IPLocation, error)
+}
+
+const (
+	ipAPIBaseURL = "http://ip-api.com"
+	ipAPIFields  = "status,country,countryCode,city,as,isp,query"
+	// ipAPIBatchSize is the most addresses ip-api accepts in one /batch request.
+	ipAPIBatchSize = 100
+	// ipAPIMaxRetries bounds how often a rate-limited request is retried.
+	ipAPIMaxRetries = 3
+)
+
+// IPAPILocator looks addresses up with the ip-api.com web service. It follows the X-Rl and X-Ttl
+// rate-limit headers: it waits for the window to reset when no requests are left and on HTTP 429.
+type IPAPILocator struct {
+	client  *http.Client
+	baseURL string
+	sleep   func(time.Duration)
+}
+
+func NewIPAPILocator(client *http.Client) *IPAPILocator {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
 	}
-	defer resp.Body.Close()
+	return &IPAPILocator{client: client, baseURL: ipAPIBaseURL, sleep: time.Sleep}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get location for IP %s", ip)
+// ipAPIResponse is the ip-api.com payload; "as" looks like "AS15169 Google LLC".
+type ipAPIResponse struct {
+	Status      string `json:"status"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	City        string `json:"city"`
+	AS          string `json:"as"`
+	ISP         string `json:"isp"`
+	Query       string `json:"query"`
+}
+
+func (r *ipAPIResponse) location() *IPLocation {
+	location := &IPLocation{
+		Country:     r.Country,
+		CountryCode: r.CountryCode,
+		City:        r.City,
+		ISP:         r.ISP,
+	}
+	asn, _, _ := strings.Cut(r.AS, " ")
+	if number, err := strconv.ParseUint(strings.TrimPrefix(asn, "AS"), 10, 32); err == nil {
+		location.ASN = uint(number)
 	}
+	return location
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (l *IPAPILocator) Locate(ip string) (*IPLocation, error) {
+	body, err := l.do(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, fmt.Sprintf("%s/json/%s?fields=%s", l.baseURL, ip, ipAPIFields), nil)
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get location for IP %s: %w", ip, err)
 	}
 
-	var location IPLocation
-	if err := json.Unmarshal(body, &location); err != nil {
+	var response ipAPIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, err
 	}
-	return &location, nil
+	if response.Status == "fail" {
+		return nil, fmt.Errorf("failed to get location for IP %s", ip)
+	}
+	return response.location(), nil
+}
+
+// LocateBatch looks addresses up through the /batch endpoint, 100 at a time.
+func (l *IPAPILocator) LocateBatch(ips []string) (map[string]*IPLocation, error) {
+	locations := make(map[string]*IPLocation, len(ips))
+	for start := 0; start < len(ips); start += ipAPIBatchSize {
+		chunk := ips[start:min(start+ipAPIBatchSize, len(ips))]
+		payload, err := json.Marshal(chunk)
+		if err != nil {
+			return locations, err
+		}
+		body, err := l.do(func() (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/batch?fields=%s", l.baseURL, ipAPIFields), bytes.NewReader(payload))
+			if err == nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			return req, err
+		})
+		if err != nil {
+			return locations, fmt.Errorf("batch location lookup failed: %w", err)
+		}
+
+		var responses []ipAPIResponse
+		if err := json.Unmarshal(body, &responses); err != nil {
+			return locations, fmt.Errorf("decode batch location response failed: %w", err)
+		}
+		for _, response := range responses {
+			if response.Status == "fail" || response.Query == "" {
+				continue
+			}
+			locations[response.Query] = response.location()
+		}
+	}
+	return locations, nil
+}
+
+// do sends a request, retrying when it is rate limited, and waits out the rate-limit window
+// after a response that used up the last request of it.
+func (l *IPAPILocator) do(newRequest func() (*http.Request, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := l.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if attempt >= ipAPIMaxRetries {
+				return nil, fmt.Errorf("rate limited after %d retries", attempt)
+			}
+			l.sleep(rateLimitWait(resp.Header, attempt))
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		if resp.Header.Get("X-Rl") == "0" {
+			l.sleep(rateLimitWait(resp.Header, attempt))
+		}
+		return body, nil
+	}
+}
+
+// rateLimitWait is the wait until the rate-limit window resets (X-Ttl seconds), falling back to
+// exponential backoff when the header is missing.
+func rateLimitWait(header http.Header, attempt int) time.Duration {
+	if ttl, err := strconv.Atoi(header.Get("X-Ttl")); err == nil && ttl >= 0 {
+		return time.Duration(ttl+1) * time.Second
+	}
+	return time.Second << attempt
+}
+
+// GetIPLocation looks ip up with ip-api.com.
+func GetIPLocation(ip string) (*IPLocation, error) {
+	return NewIPAPILocator(nil).Locate(ip)
 }
@@ -0,0 +1,135 @@
+package ip
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestIPAPILocator(server *httptest.Server, waits *[]time.Duration) *IPAPILocator {
+	locator := NewIPAPILocator(server.Client())
+	locator.baseURL = server.URL
+	locator.sleep = func(wait time.Duration) {
+		*waits = append(*waits, wait)
+	}
+	return locator
+}
+
+func TestIPAPILocatorLocate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/203.0.113.7" {
+			t.Errorf("unexpected request path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"status":"success","country":"United States","countryCode":"US","city":"Los Angeles","as":"AS64500 Example Transit","isp":"Example ISP","query":"203.0.113.7"}`))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	location, err := newTestIPAPILocator(server, &waits).Locate("203.0.113.7")
+	if err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	expected := IPLocation{Country: "United States", CountryCode: "US", City: "Los Angeles", ASN: 64500, ISP: "Example ISP"}
+	if *location != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *location)
+	}
+	if len(waits) != 0 {
+		t.Fatalf("expected no rate-limit wait, got %v", waits)
+	}
+}
+
+func TestIPAPILocatorRetriesWhenRateLimited(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("X-Ttl", "4")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		// The last request of the window: the next one has to wait for the reset.
+		w.Header().Set("X-Rl", "0")
+		w.Header().Set("X-Ttl", "9")
+		w.Write([]byte(`{"status":"success","countryCode":"JP","query":"198.51.100.20"}`))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	location, err := newTestIPAPILocator(server, &waits).Locate("198.51.100.20")
+	if err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if location.CountryCode != "JP" {
+		t.Fatalf("expected JP, got %+v", *location)
+	}
+	if len(waits) != 2 || waits[0] != 5*time.Second || waits[1] != 10*time.Second {
+		t.Fatalf("expected waits of 5s and 10s, got %v", waits)
+	}
+}
+
+func TestIPAPILocatorGivesUpAfterRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	if _, err := newTestIPAPILocator(server, &waits).Locate("203.0.113.7"); err == nil {
+		t.Fatalf("expected a persistently rate-limited lookup to fail")
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if len(waits) != len(expected) {
+		t.Fatalf("expected exponential backoff %v, got %v", expected, waits)
+	}
+	for i := range expected {
+		if waits[i] != expected[i] {
+			t.Fatalf("expected exponential backoff %v, got %v", expected, waits)
+		}
+	}
+}
+
+func TestIPAPILocatorLocateBatch(t *testing.T) {
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/batch" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var ips []string
+		json.NewDecoder(r.Body).Decode(&ips)
+		batches = append(batches, ips)
+		responses := make([]map[string]string, 0, len(ips))
+		for _, ip := range ips {
+			if ip == "192.0.2.1" {
+				responses = append(responses, map[string]string{"status": "fail", "query": ip})
+				continue
+			}
+			responses = append(responses, map[string]string{"status": "success", "countryCode": "US", "query": ip})
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	ips := make([]string, 0, 150)
+	for i := range 149 {
+		ips = append(ips, fmt.Sprintf("10.0.%d.%d", i/100, i%100))
+	}
+	ips = append(ips, "192.0.2.1")
+
+	var waits []time.Duration
+	locations, err := newTestIPAPILocator(server, &waits).LocateBatch(ips)
+	if err != nil {
+		t.Fatalf("batch locate failed: %v", err)
+	}
+	if len(batches) != 2 || len(batches[0]) != 100 || len(batches[1]) != 50 {
+		t.Fatalf("expected batches of 100 and 50, got %d batches", len(batches))
+	}
+	if len(locations) != 149 {
+		t.Fatalf("expected 149 located addresses, got %d", len(locations))
+	}
+	if _, ok := locations["192.0.2.1"]; ok {
+		t.Fatalf("expected failed lookups to be left out")
+	}
+}
@@ -0,0 +1,86 @@
+package ip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// mmdbRecord covers the fields shared by the MaxMind GeoIP2/GeoLite2 and DB-IP City and ASN
+// databases, so either vendor's files can be used.
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// MMDBLocator looks addresses up in local .mmdb files, without any network request.
+type MMDBLocator struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+// OpenMMDBLocator opens a country or city database and, optionally, a separate ASN database.
+func OpenMMDBLocator(cityPath, asnPath string) (*MMDBLocator, error) {
+	if cityPath == "" {
+		return nil, errors.New("geoip database path is empty")
+	}
+	city, err := maxminddb.Open(cityPath)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database %s failed: %w", cityPath, err)
+	}
+	locator := &MMDBLocator{city: city}
+	if asnPath != "" {
+		asn, err := maxminddb.Open(asnPath)
+		if err != nil {
+			city.Close()
+			return nil, fmt.Errorf("open asn database %s failed: %w", asnPath, err)
+		}
+		locator.asn = asn
+	}
+	return locator, nil
+}
+
+func (l *MMDBLocator) Locate(ip string) (*IPLocation, error) {
+	addr, err := resolveAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	var record mmdbRecord
+	if err := l.city.Lookup(net.IP(addr.AsSlice()), &record); err != nil {
+		return nil, fmt.Errorf("lookup %s failed: %w", ip, err)
+	}
+	location := &IPLocation{
+		Country:     record.Country.Names["en"],
+		CountryCode: record.Country.ISOCode,
+		City:        record.City.Names["en"],
+		ASN:         record.ASN,
+		ISP:         record.Organization,
+	}
+	if l.asn != nil {
+		var asnRecord mmdbRecord
+		if err := l.asn.Lookup(net.IP(addr.AsSlice()), &asnRecord); err != nil {
+			return nil, fmt.Errorf("lookup asn of %s failed: %w", ip, err)
+		}
+		location.ASN = asnRecord.ASN
+		location.ISP = asnRecord.Organization
+	}
+	return location, nil
+}
+
+func (l *MMDBLocator) Close() error {
+	err := l.city.Close()
+	if l.asn != nil {
+		err = errors.Join(err, l.asn.Close())
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package ip
+
+import "testing"
+
+// testdata/city.mmdb and testdata/asn.mmdb hold a few documentation-range networks. They are
+// written by testdata/gen, which has its own module so mmdbwriter stays out of go.mod.
+//go:generate go -C testdata/gen run . -out ..
+
+func TestMMDBLocatorLocate(t *testing.T) {
+	locator, err := OpenMMDBLocator("testdata/city.mmdb", "testdata/asn.mmdb")
+	if err != nil {
+		t.Fatalf("open mmdb locator failed: %v", err)
+	}
+	defer locator.Close()
+
+	tests := []struct {
+		ip       string
+		expected IPLocation
+	}{
+		{ip: "203.0.113.7", expected: IPLocation{Country: "United States", CountryCode: "US", City: "Los Angeles", ASN: 64500, ISP: "Example Transit"}},
+		{ip: "198.51.100.20", expected: IPLocation{Country: "Japan", CountryCode: "JP", City: "Tokyo"}},
+		{ip: "2001:db8::1", expected: IPLocation{Country: "Germany", CountryCode: "DE", City: "Frankfurt am Main", ASN: 64501, ISP: "Example Hosting"}},
+		{ip: "::ffff:203.0.113.7", expected: IPLocation{Country: "United States", CountryCode: "US", City: "Los Angeles", ASN: 64500, ISP: "Example Transit"}},
+		{ip: "192.0.2.1", expected: IPLocation{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			location, err := locator.Locate(tt.ip)
+			if err != nil {
+				t.Fatalf("locate failed: %v", err)
+			}
+			if *location != tt.expected {
+				t.Fatalf("expected %+v, got %+v", tt.expected, *location)
+			}
+		})
+	}
+}
+
+func TestMMDBLocatorWithoutASNDatabase(t *testing.T) {
+	locator, err := OpenMMDBLocator("testdata/city.mmdb", "")
+	if err != nil {
+		t.Fatalf("open mmdb locator failed: %v", err)
+	}
+	defer locator.Close()
+
+	location, err := locator.Locate("203.0.113.7")
+	if err != nil {
+		t.Fatalf("locate failed: %v", err)
+	}
+	if location.CountryCode != "US" || location.ASN != 0 {
+		t.Fatalf("expected country without asn, got %+v", *location)
+	}
+}
+
+func TestOpenMMDBLocatorErrors(t *testing.T) {
+	if _, err := OpenMMDBLocator("", ""); err == nil {
+		t.Fatalf("expected an empty path to fail")
+	}
+	if _, err := OpenMMDBLocator("testdata/missing.mmdb", ""); err == nil {
+		t.Fatalf("expected a missing database to fail")
+	}
+	if _, err := OpenMMDBLocator("testdata/city.mmdb", "testdata/missing.mmdb"); err == nil {
+		t.Fatalf("expected a missing asn database to fail")
+	}
+}
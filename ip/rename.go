@@ -3,6 +3,8 @@ package ip
 import (
 	"bytes"
 	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"text/template"
 	"time"
@@ -28,45 +30,158 @@ var countryFlags = map[string]string{
 // DefaultNameTemplate is the built-in format when -rename-template is not set.
 const DefaultNameTemplate = `{{.Flag}} {{.CountryCode}} {{.Index}} | {{.Direction}} {{.Speed}}{{.SpeedUnit}}`
 
+// NodeInfo is what is known about a tested node when it is renamed.
+type NodeInfo struct {
+	Location      *IPLocation // geolocation of the exit IP, or of the entry server as a fallback
+	Type          string      // protocol type, e.g. Trojan
+	OriginalName  string      // node name before renaming
+	Address       string      // server:port, keys the hash index strategy
+	Provider      string      // proxy provider the node came from; empty for inline proxies
+	ExitIP        string
+	Latency       time.Duration
+	Jitter        time.Duration
+	PacketLoss    float64           // percent
+	DownloadSpeed float64           // bytes/s
+	UploadSpeed   float64           // bytes/s
+	Capture       map[string]string // named groups extracted from the original name, see NameCapture
+}
+
 // NodeNameData is the data passed to the rename template.
 type NodeNameData struct {
-	Flag              string // country flag emoji
-	CountryCode       string // e.g. US, HK
-	Index             string // padded number, e.g. 001
-	Direction         string // ⬇️, ⬆️, or ⚡
-	Speed             string // primary metric value
-	SpeedUnit         string // MB/s or ms
-	LatencyMs         string // latency in milliseconds
-	DownloadSpeedMBps string // download MB/s
-	UploadSpeedMBps   string // upload MB/s
-}
-
-// GenerateNodeNameFromTemplate renders name from a text/template. Placeholders:
-// {{.Flag}}, {{.CountryCode}}, {{.Index}}, {{.Direction}}, {{.Speed}}, {{.SpeedUnit}}, {{.LatencyMs}}, {{.DownloadSpeedMBps}}, {{.UploadSpeedMBps}}.
-// If template is empty, DefaultNameTemplate is used. On execute error, falls back to default format.
-func GenerateNodeNameFromTemplate(tmpl string, countryCode string, latency time.Duration, downloadSpeed, uploadSpeed float64, nameCount map[string]int) (string, error) {
-	if tmpl == "" {
-		tmpl = DefaultNameTemplate
+	Flag              string            // country flag emoji
+	CountryCode       string            // e.g. US, HK
+	Country           string            // country name, e.g. Japan
+	City              string            // e.g. Tokyo; empty when unknown
+	ASN               string            // e.g. AS64500; empty when unknown
+	ISP               string            // ISP or AS organization
+	Type              string            // protocol type, e.g. Trojan
+	OriginalName      string            // node name before renaming
+	Provider          string            // proxy provider name; empty for inline proxies
+	ExitIP            string            // exit IP; empty when not probed
+	Index             string            // padded number, e.g. 001, or hex digits with IndexHash
+	Direction         string            // ⬇️, ⬆️, or ⚡
+	Speed             string            // primary metric value
+	SpeedUnit         string            // MB/s or ms
+	LatencyMs         string            // latency in milliseconds
+	JitterMs          string            // jitter in milliseconds
+	PacketLoss        string            // packet loss percentage, e.g. 0.0
+	DownloadSpeedMBps string            // download MB/s
+	UploadSpeedMBps   string            // upload MB/s
+	Capture           map[string]string // named groups from the original name, e.g. {{.Capture.multiplier}}
+}
+
+// templateFuncs are the helpers available in rename templates. Functions taking the value last
+// can be used in pipelines, e.g. {{.ISP | trunc 8}} or {{.City | default "Unknown"}}.
+var templateFuncs = template.FuncMap{
+	"upper":        strings.ToUpper,
+	"lower":        strings.ToLower,
+	"trunc":        truncate,
+	"printf":       fmt.Sprintf,
+	"regexReplace": regexReplace,
+	"default":      defaultValue,
+}
+
+// truncate keeps the first n characters of value.
+func truncate(n int, value string) string {
+	runes := []rune(value)
+	if n < 0 || len(runes) <= n {
+		return value
 	}
-	t, err := template.New("name").Parse(tmpl)
+	return string(runes[:n])
+}
+
+func regexReplace(pattern, replacement, value string) (string, error) {
+	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return "", err
 	}
-	data := buildNodeNameData(countryCode, latency, downloadSpeed, uploadSpeed, nameCount)
+	return re.ReplaceAllString(value, replacement), nil
+}
+
+// defaultValue returns fallback when value is empty or the zero value of its type.
+func defaultValue(fallback, value any) any {
+	if value == nil {
+		return fallback
+	}
+	if reflected := reflect.ValueOf(value); reflected.IsZero() {
+		return fallback
+	}
+	return value
+}
+
+// Renamer names nodes with a template and an index strategy, numbering nodes across calls.
+type Renamer struct {
+	template  *template.Template
+	strategy  IndexStrategy
+	nameCount map[string]int
+}
+
+// NewRenamer parses tmpl once; an empty tmpl uses DefaultNameTemplate.
+func NewRenamer(tmpl string, strategy IndexStrategy) (*Renamer, error) {
+	t, err := parseNameTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if strategy == "" {
+		strategy = IndexSequential
+	}
+	return &Renamer{template: t, strategy: strategy, nameCount: make(map[string]int)}, nil
+}
+
+// Name renders the name of node, falling back to the default format when the template fails.
+func (r *Renamer) Name(node NodeInfo) string {
+	return renderNodeName(r.template, buildNodeNameData(node, r.strategy, r.nameCount))
+}
+
+// GenerateNodeNameFromTemplate renders name from a text/template for a node known only by its
+// country code and speed test results. Use GenerateNodeNameFromNode to fill the other fields.
+func GenerateNodeNameFromTemplate(tmpl string, countryCode string, latency time.Duration, downloadSpeed, uploadSpeed float64, nameCount map[string]int) (string, error) {
+	return GenerateNodeNameFromNode(tmpl, NodeInfo{
+		Location:      &IPLocation{CountryCode: countryCode},
+		Latency:       latency,
+		DownloadSpeed: downloadSpeed,
+		UploadSpeed:   uploadSpeed,
+	}, nameCount)
+}
+
+// GenerateNodeNameFromNode renders name from a text/template over NodeNameData, with the
+// helpers upper, lower, trunc, printf, regexReplace and default. Nodes are numbered per country.
+// If template is empty, DefaultNameTemplate is used. On execute error, falls back to default format.
+func GenerateNodeNameFromNode(tmpl string, node NodeInfo, nameCount map[string]int) (string, error) {
+	t, err := parseNameTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	return renderNodeName(t, buildNodeNameData(node, IndexSequential, nameCount)), nil
+}
+
+func parseNameTemplate(tmpl string) (*template.Template, error) {
+	if tmpl == "" {
+		tmpl = DefaultNameTemplate
+	}
+	return template.New("name").Option("missingkey=zero").Funcs(templateFuncs).Parse(tmpl)
+}
+
+func renderNodeName(t *template.Template, data NodeNameData) string {
 	var buf bytes.Buffer
 	if err := t.Execute(&buf, data); err != nil {
 		// fallback to default format so caller does not double-increment nameCount
-		return fmt.Sprintf("%s %s %s | %s %s%s", data.Flag, data.CountryCode, data.Index, data.Direction, data.Speed, data.SpeedUnit), nil
+		return fmt.Sprintf("%s %s %s | %s %s%s", data.Flag, data.CountryCode, data.Index, data.Direction, data.Speed, data.SpeedUnit)
 	}
-	return buf.String(), nil
+	return buf.String()
 }
 
-func buildNodeNameData(countryCode string, latency time.Duration, downloadSpeed, uploadSpeed float64, nameCount map[string]int) NodeNameData {
-	flag, exists := countryFlags[strings.ToUpper(countryCode)]
+func buildNodeNameData(node NodeInfo, strategy IndexStrategy, nameCount map[string]int) NodeNameData {
+	location := node.Location
+	if location == nil {
+		location = &IPLocation{}
+	}
+	latency, downloadSpeed, uploadSpeed := node.Latency, node.DownloadSpeed, node.UploadSpeed
+	upperCountryCode := strings.ToUpper(location.CountryCode)
+	flag, exists := countryFlags[upperCountryCode]
 	if !exists {
 		flag = "🏳️"
 	}
-	upperCountryCode := strings.ToUpper(countryCode)
 	speed := downloadSpeed
 	direction := "⬇️"
 	speedUnit := "MB/s"
@@ -83,24 +198,46 @@ func buildNodeNameData(countryCode string, latency time.Duration, downloadSpeed,
 	if speedUnit == "ms" {
 		speedMBps = speed
 	}
-	count := nameCount[upperCountryCode] + 1
-	nameCount[upperCountryCode] = count
+	index := strategy.index(node, upperCountryCode, nameCount)
 	dlMBps := downloadSpeed / (1024 * 1024)
 	ulMBps := uploadSpeed / (1024 * 1024)
 	latencyMs := "N/A"
 	if latency > 0 {
 		latencyMs = fmt.Sprintf("%d", latency.Milliseconds())
 	}
+	jitterMs := "N/A"
+	if node.Jitter > 0 {
+		jitterMs = fmt.Sprintf("%d", node.Jitter.Milliseconds())
+	}
+	capture := node.Capture
+	if capture == nil {
+		capture = map[string]string{}
+	}
+	asn := ""
+	if location.ASN > 0 {
+		asn = fmt.Sprintf("AS%d", location.ASN)
+	}
 	return NodeNameData{
 		Flag:              flag,
 		CountryCode:       upperCountryCode,
-		Index:             fmt.Sprintf("%03d", count),
+		Country:           location.Country,
+		City:              location.City,
+		ASN:               asn,
+		ISP:               location.ISP,
+		Type:              node.Type,
+		OriginalName:      node.OriginalName,
+		Provider:          node.Provider,
+		ExitIP:            node.ExitIP,
+		Index:             index,
 		Direction:         direction,
 		Speed:             fmt.Sprintf("%.2f", speedMBps),
 		SpeedUnit:         speedUnit,
 		LatencyMs:         latencyMs,
+		JitterMs:          jitterMs,
+		PacketLoss:        fmt.Sprintf("%.1f", node.PacketLoss),
 		DownloadSpeedMBps: fmt.Sprintf("%.2f", dlMBps),
 		UploadSpeedMBps:   fmt.Sprintf("%.2f", ulMBps),
+		Capture:           capture,
 	}
 }
 
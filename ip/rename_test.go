@@ -117,3 +117,61 @@ func TestGenerateNodeNameFromTemplateFastModeLatencyField(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, name)
 	}
 }
+
+func TestGenerateNodeNameFromNode(t *testing.T) {
+	node := NodeInfo{
+		Location:      &IPLocation{Country: "Japan", CountryCode: "JP", City: "Tokyo", ASN: 64500, ISP: "Example Transit Networks"},
+		Type:          "Trojan",
+		OriginalName:  "JP Premium 01",
+		Provider:      "airport",
+		ExitIP:        "203.0.113.7",
+		Latency:       80 * time.Millisecond,
+		Jitter:        12 * time.Millisecond,
+		PacketLoss:    2.5,
+		DownloadSpeed: 10 * 1024 * 1024,
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+	}{
+		{name: "request example", tmpl: "{{.Flag}} {{.City}} {{.ISP | trunc 8}} {{.Type}}", expected: "🇯🇵 Tokyo Example  Trojan"},
+		{name: "network fields", tmpl: "{{.ASN}} {{.ExitIP}} {{.Provider}}", expected: "AS64500 203.0.113.7 airport"},
+		{name: "quality fields", tmpl: "{{.JitterMs}}ms {{.PacketLoss}}%", expected: "12ms 2.5%"},
+		{name: "case helpers", tmpl: "{{.Type | upper}} {{.Country | lower}}", expected: "TROJAN japan"},
+		{name: "printf", tmpl: `{{printf "%s-%s" .CountryCode .Index}}`, expected: "JP-001"},
+		{name: "regexReplace", tmpl: `{{.OriginalName | regexReplace "\\s+\\d+$" ""}}`, expected: "JP Premium"},
+		{name: "default keeps value", tmpl: `{{.City | default "Unknown"}}`, expected: "Tokyo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := GenerateNodeNameFromNode(tt.tmpl, node, make(map[string]int))
+			if err != nil {
+				t.Fatalf("template error: %v", err)
+			}
+			if name != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, name)
+			}
+		})
+	}
+}
+
+func TestTemplateDefaultFillsMissingData(t *testing.T) {
+	name, err := GenerateNodeNameFromNode(`{{.City | default "Unknown"}} {{.ASN | default "AS?"}}`, NodeInfo{Location: &IPLocation{CountryCode: "US"}}, make(map[string]int))
+	if err != nil {
+		t.Fatalf("template error: %v", err)
+	}
+	if name != "Unknown AS?" {
+		t.Fatalf("expected defaults for missing data, got %q", name)
+	}
+}
+
+func TestTruncateCountsCharacters(t *testing.T) {
+	if got := truncate(2, "香港节点"); got != "香港" {
+		t.Fatalf("expected truncation by character, got %q", got)
+	}
+	if got := truncate(10, "short"); got != "short" {
+		t.Fatalf("expected short values to be kept, got %q", got)
+	}
+}
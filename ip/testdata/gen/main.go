@@ -0,0 +1,105 @@
+// Command gen writes the city.mmdb and asn.mmdb fixtures used by the ip package tests.
+// It lives in its own module so that mmdbwriter does not become a dependency of the tool.
+//
+// Regenerate the fixtures with `go generate ./ip` from the repository root.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// buildEpoch is fixed so that regenerating the fixtures is reproducible.
+const buildEpoch = 1792288981
+
+type cityRecord struct {
+	network     string
+	countryCode string
+	country     string
+	city        string
+}
+
+type asnRecord struct {
+	network string
+	number  uint32
+	org     string
+}
+
+// Only documentation ranges are used, so the fixtures never describe real networks.
+var cities = []cityRecord{
+	{network: "198.51.100.0/24", countryCode: "JP", country: "Japan", city: "Tokyo"},
+	{network: "203.0.113.0/24", countryCode: "US", country: "United States", city: "Los Angeles"},
+	{network: "2001:db8::/32", countryCode: "DE", country: "Germany", city: "Frankfurt am Main"},
+}
+
+var asns = []asnRecord{
+	{network: "203.0.113.0/24", number: 64500, org: "Example Transit"},
+	{network: "2001:db8::/32", number: 64501, org: "Example Hosting"},
+}
+
+func main() {
+	out := flag.String("out", ".", "directory to write the fixtures to")
+	flag.Parse()
+
+	city := newTree("Test-City")
+	for _, record := range cities {
+		insert(city, record.network, mmdbtype.Map{
+			"city": mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String(record.city)}},
+			"country": mmdbtype.Map{
+				"iso_code": mmdbtype.String(record.countryCode),
+				"names":    mmdbtype.Map{"en": mmdbtype.String(record.country)},
+			},
+		})
+	}
+	write(city, filepath.Join(*out, "city.mmdb"))
+
+	asn := newTree("Test-ASN")
+	for _, record := range asns {
+		insert(asn, record.network, mmdbtype.Map{
+			"autonomous_system_number":       mmdbtype.Uint32(record.number),
+			"autonomous_system_organization": mmdbtype.String(record.org),
+		})
+	}
+	write(asn, filepath.Join(*out, "asn.mmdb"))
+}
+
+func newTree(databaseType string) *mmdbwriter.Tree {
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		BuildEpoch:              buildEpoch,
+		DatabaseType:            databaseType,
+		IncludeReservedNetworks: true,
+		IPVersion:               6,
+		RecordSize:              24,
+	})
+	if err != nil {
+		log.Fatalf("create %s tree failed: %s", databaseType, err)
+	}
+	return tree
+}
+
+func insert(tree *mmdbwriter.Tree, network string, value mmdbtype.Map) {
+	_, ipNet, err := net.ParseCIDR(network)
+	if err != nil {
+		log.Fatalf("parse network %s failed: %s", network, err)
+	}
+	if err := tree.Insert(ipNet, value); err != nil {
+		log.Fatalf("insert %s failed: %s", network, err)
+	}
+}
+
+func write(tree *mmdbwriter.Tree, path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("create %s failed: %s", path, err)
+	}
+	defer file.Close()
+	if _, err := tree.WriteTo(file); err != nil {
+		log.Fatalf("write %s failed: %s", path, err)
+	}
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/faceair/clash-speedtest/convert"
 	"github.com/faceair/clash-speedtest/gist"
 	"github.com/faceair/clash-speedtest/ip"
 	"github.com/faceair/clash-speedtest/output"
+	"github.com/faceair/clash-speedtest/report"
 	"github.com/faceair/clash-speedtest/speedtester"
 	"github.com/faceair/clash-speedtest/tui"
 	mihomolog "github.com/metacubex/mihomo/log"
@@ -30,12 +38,29 @@ var (
 	filterRegexConfig = flag.String("f", ".+", "filter proxies by name, use regexp")
 	blockKeywords     = flag.String("b", "", "block proxies by keywords, use | to separate multiple keywords (example: -b 'rate|x1|1x')")
 	serverURL         = flag.String("server-url", "https://dl.google.com/chrome/mac/universal/stable/GGRO/googlechrome.dmg", "server url or direct download url")
-	speedMode         = flag.String("speed-mode", "download", "speed test mode: fast, download, full")
+	speedMode         = flag.String("speed-mode", "download", "speed test mode: fast, download, full, sustained")
 	downloadSize      = flag.Int("download-size", 50*1024*1024, "download size for testing proxies")
 	uploadSize        = flag.Int("upload-size", 20*1024*1024, "upload size for testing proxies (full mode only)")
+	testDuration      = flag.Duration("test-duration", 0, "transfer for this long per node instead of a fixed download/upload size (example: 10s, 0 = size based)")
+	sampleInterval    = flag.Duration("sample-interval", 250*time.Millisecond, "throughput sampling interval for peak/steady speed and stall detection")
 	timeout           = flag.Duration("timeout", time.Second*5, "timeout for testing proxies")
 	concurrent        = flag.Int("concurrent", 4, "download concurrent size")
+	transferPolicy    = flag.String("transfer-policy", "strict", "how failed streams affect the speed: strict (any failure zeroes it), best-effort, min-success")
+	minSuccessRatio   = flag.Float64("min-success-ratio", 0.5, "share of streams that must succeed with -transfer-policy min-success")
+	scaleConcurrency  = flag.Bool("scale-concurrency", false, "also measure download speed at each of -scaling-streams to detect per-connection throttling")
+	scalingStreams    = flag.String("scaling-streams", "1,2,4,8", "comma separated stream counts for -scale-concurrency")
+	sustainedDuration = flag.Duration("sustained-duration", 30*time.Second, "length of the single-stream transfer used for throttle detection in sustained mode")
+	payloadType       = flag.String("payload", "zero", "transfer payload: zero or random (random avoids inflated speeds on compressing proxies)")
+	exitIPURL         = flag.String("exit-ip-url", "", "IP echo endpoint requested through each proxy to find its exit IP for renaming, e.g. http://your-server-ip:8080/__ip (empty = use entry server)")
+	verifyPayload     = flag.Bool("verify-payload", false, "verify downloaded content and request headers against the download-server to detect tampering proxies (download-server only)")
+	pingCount         = flag.Int("ping-count", 6, "number of latency probes per proxy")
+	pingInterval      = flag.Duration("ping-interval", 100*time.Millisecond, "interval between latency probes")
+	proxyConcurrency  = flag.Int("proxy-concurrency", 1, "number of proxies tested in parallel")
+	serverConcurrency = flag.Int("server-concurrency", 1, "max proxies sharing the same entry server tested in parallel (0 = unlimited)")
 	outputPath        = flag.String("output", "", "output config file path")
+	outputFormat      = flag.String("output-format", "clash", "format of the output file: clash (mihomo YAML), singbox (sing-box outbounds JSON), uri (share links, one per line) or base64 (base64 encoded share links)")
+	singBoxGroup      = flag.String("singbox-group", "none", "group outbound added in front of the nodes with -output-format singbox: none, urltest or selector")
+	reportPath        = flag.String("report", "", "write a self-contained HTML report of all tested nodes to this path (uploaded next to the output when -gist-token is set)")
 	gistToken         = flag.String("gist-token", "", "github gist token for updating output")
 	gistAddress       = flag.String("gist-address", "", "github gist address or id for updating output (filename uses output basename)")
 	repoToken         = flag.String("repo-token", "", "github token for updating repository file")
@@ -44,12 +69,24 @@ var (
 	repoBranch        = flag.String("repo-branch", "", "repository branch for uploading output (default: repository default branch)")
 	maxLatency        = flag.Duration("max-latency", time.Second, "filter latency greater than this value")
 	maxPacketLoss     = flag.Float64("max-packet-loss", 100, "filter packet loss greater than this value(unit: %)")
+	maxLoadedLatency  = flag.Duration("max-loaded-latency", 0, "filter latency under load greater than this value (0 = disabled)")
+	maxBufferbloat    = flag.Duration("max-bufferbloat", 0, "filter latency increase under load greater than this value (0 = disabled)")
 	minDownloadSpeed  = flag.Float64("min-download-speed", 5, "filter download speed less than this value(unit: MB/s)")
 	minUploadSpeed    = flag.Float64("min-upload-speed", 2, "filter upload speed less than this value(unit: MB/s, full mode only)")
 	renameNodes       = flag.Bool("rename", true, "rename nodes with IP location and speed")
-	renameTemplate    = flag.String("rename-template", "", "name template for renaming (Go text/template). Placeholders: {{.Flag}}, {{.CountryCode}}, {{.Index}}, {{.Direction}}, {{.Speed}}, {{.SpeedUnit}}, {{.LatencyMs}}, {{.DownloadSpeedMBps}}, {{.UploadSpeedMBps}}. Empty = default format")
+	geoipDB           = flag.String("geoip-db", "", "local MaxMind/DB-IP country or city .mmdb file for renaming (empty = ip-api.com)")
+	geoipCache        = flag.String("geoip-cache", ip.DefaultCachePath(), "on-disk cache of ip-api.com lookups (empty = disabled)")
+	geoipCacheTTL     = flag.Duration("geoip-cache-ttl", 7*24*time.Hour, "how long cached ip-api.com lookups stay valid")
+	geoipASNDB        = flag.String("geoip-asn-db", "", "optional local MaxMind/DB-IP ASN .mmdb file, used with -geoip-db")
+	renameTemplate    = flag.String("rename-template", "", "name template for renaming (Go text/template). Placeholders: {{.Flag}}, {{.CountryCode}}, {{.Country}}, {{.City}}, {{.ASN}}, {{.ISP}}, {{.Type}}, {{.OriginalName}}, {{.Provider}}, {{.ExitIP}}, {{.Capture.<group>}}, {{.Index}}, {{.Direction}}, {{.Speed}}, {{.SpeedUnit}}, {{.LatencyMs}}, {{.JitterMs}}, {{.PacketLoss}}, {{.DownloadSpeedMBps}}, {{.UploadSpeedMBps}}. Functions: upper, lower, trunc, printf, regexReplace, default. Empty = default format")
+	renameIndex       = flag.String("rename-index", "sequential", "how {{.Index}} is numbered when renaming: sequential (per country), hash (stable hash of server:port) or country-type (per country and protocol)")
+	renameCapture     = flag.String("rename-capture", "", "regex with named groups matched against the original node name, e.g. '(?P<multiplier>x[\\d.]+)'; groups are available as {{.Capture.<group>}} in -rename-template")
+	renameCaptureMiss = flag.String("rename-capture-fallback", "", "value of capture groups for nodes whose name does not match -rename-capture")
+	resultFormat      = flag.String("format", "auto", "result format on stdout: auto (TUI on a terminal, TSV otherwise), tsv, csv, markdown, json (one array at the end) or jsonl (one object per result)")
+	sortResults       = flag.Bool("sort", false, "write non-interactive results sorted once all tests finish, instead of streaming them as they complete")
 	fastMode          = flag.Bool("fast", false, "fast mode (alias for --speed-mode fast)")
 	versionFlag       = flag.Bool("v", false, "show version information")
+	udpServer         = flag.String("udp-server", "", "UDP echo server address (host:port) for testing UDP relay, e.g. the download-server address; empty disables the UDP test")
 	userAgent         = flag.String("ua", "", "User-Agent for fetching config from http(s) URL (default: mihomo kernel UA, e.g. mihomo/1.10.0)")
 )
 
@@ -72,52 +109,132 @@ func main() {
 	if !*fastMode {
 		requestedMode, err = speedtester.ParseSpeedMode(*speedMode)
 		if err != nil {
-			log.Fatalln("parse speed mode failed: %s", err)
-		}
-	}
-
-	speedTester, err := speedtester.New(&speedtester.Config{
-		ConfigPaths:      *configPathsConfig,
-		FilterRegex:      *filterRegexConfig,
-		BlockRegex:       *blockKeywords,
-		ServerURL:        *serverURL,
-		DownloadSize:     *downloadSize,
-		UploadSize:       *uploadSize,
-		Timeout:          *timeout,
-		Concurrent:       *concurrent,
-		MaxPacketLoss:    *maxPacketLoss,
-		MaxLatency:       *maxLatency,
-		MinDownloadSpeed: *minDownloadSpeed * 1024 * 1024,
-		MinUploadSpeed:   *minUploadSpeed * 1024 * 1024,
-		Mode:             requestedMode,
-		OutputPath:       *outputPath,
-		UserAgent:        *userAgent,
-	})
+			log.Fatalf("parse speed mode failed: %s", err)
+		}
+	}
+
+	policy, err := speedtester.ParseTransferPolicy(*transferPolicy)
+	if err != nil {
+		log.Fatalf("parse transfer policy failed: %s", err)
+	}
+
+	payload, err := speedtester.ParsePayloadType(*payloadType)
+	if err != nil {
+		log.Fatalf("parse payload type failed: %s", err)
+	}
+	streamCounts, err := speedtester.ParseStreamCounts(*scalingStreams)
+	if err != nil {
+		log.Fatalf("parse scaling streams failed: %s", err)
+	}
+	indexStrategy, err := ip.ParseIndexStrategy(*renameIndex)
+	if err != nil {
+		log.Fatalf("parse rename index failed: %s", err)
+	}
+	exportFormat, err := convert.ParseExportFormat(*outputFormat)
+	if err != nil {
+		log.Fatalf("parse export format failed: %s", err)
+	}
+	group, err := convert.ParseSingBoxGroup(*singBoxGroup)
+	if err != nil {
+		log.Fatalf("parse sing-box group failed: %s", err)
+	}
+	exporter := &proxyExporter{format: exportFormat, group: group}
+
+	renamer, err := ip.NewRenamer(*renameTemplate, indexStrategy)
+	if err != nil {
+		log.Printf("rename template parse error: %s, use default name", err)
+		renamer, _ = ip.NewRenamer("", indexStrategy)
+	}
+	var nameCapture *ip.NameCapture
+	if *renameCapture != "" {
+		nameCapture, err = ip.NewNameCapture(*renameCapture, *renameCaptureMiss)
+		if err != nil {
+			log.Fatalf("parse rename capture failed: %s", err)
+		}
+	}
+
+	format, err := output.ParseFormat(*resultFormat)
+	if err != nil {
+		log.Fatalf("parse output format failed: %s", err)
+	}
+
+	startedAt := time.Now()
+	config := &speedtester.Config{
+		ConfigPaths:        *configPathsConfig,
+		FilterRegex:        *filterRegexConfig,
+		BlockRegex:         *blockKeywords,
+		ServerURL:          *serverURL,
+		DownloadSize:       *downloadSize,
+		UploadSize:         *uploadSize,
+		TestDuration:       *testDuration,
+		SampleInterval:     *sampleInterval,
+		Timeout:            *timeout,
+		Concurrent:         *concurrent,
+		TransferPolicy:     policy,
+		MinSuccessRatio:    *minSuccessRatio,
+		PingCount:          *pingCount,
+		PingInterval:       *pingInterval,
+		ProxyConcurrency:   *proxyConcurrency,
+		ServerConcurrency:  *serverConcurrency,
+		MaxPacketLoss:      *maxPacketLoss,
+		MaxLatency:         *maxLatency,
+		MinDownloadSpeed:   *minDownloadSpeed * 1024 * 1024,
+		MinUploadSpeed:     *minUploadSpeed * 1024 * 1024,
+		Mode:               requestedMode,
+		OutputPath:         *outputPath,
+		UserAgent:          *userAgent,
+		UDPServer:          *udpServer,
+		ConcurrencyScaling: *scaleConcurrency,
+		ScalingStreams:     streamCounts,
+		SustainedDuration:  *sustainedDuration,
+		VerifyPayload:      *verifyPayload,
+		PayloadType:        payload,
+		ExitIPURL:          *exitIPURL,
+	}
+	speedTester, err := speedtester.New(config)
 	if err != nil {
-		log.Fatalln("create speed tester failed: %s", err)
+		log.Fatalf("create speed tester failed: %s", err)
 	}
 	effectiveMode := speedTester.Mode()
 
 	allProxies, err := speedTester.LoadProxies()
 	if err != nil {
-		log.Fatalln("load proxies failed: %s", err)
+		log.Fatalf("load proxies failed: %s", err)
 	}
 
-	outputMode := output.DetermineOutputMode(output.IsTerminalFile)
+	outputMode := output.ResolveOutputMode(format, output.IsTerminalFile)
 
-	var tsvWriter *output.TSVWriter
+	var resultWriter output.ResultWriter
 	if outputMode == output.OutputModeTSV {
-		var err error
-		tsvWriter, err = output.NewTSVWriter(os.Stdout, effectiveMode)
+		run := output.RunInfo{
+			Version:    version,
+			Commit:     commit,
+			StartedAt:  startedAt,
+			Parameters: output.NewTestParameters(config),
+		}
+		resultWriter, err = output.NewResultWriter(format, os.Stdout, effectiveMode, run)
 		if err != nil {
-			log.Fatalln("create TSV writer failed: %s", err)
+			log.Fatalf("create %s writer failed: %s", format, err)
+		}
+		if *sortResults {
+			resultWriter = output.NewSortedWriter(resultWriter, effectiveMode)
 		}
 	}
 
 	results := make([]*speedtester.Result, 0, len(allProxies))
 
+	// SIGINT/SIGTERM and quitting the TUI cancel the run; partial results are still saved.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	// Saving, geolocation and uploads do not watch ctx. Restoring the default signal handling
+	// after the first signal lets a second Ctrl-C kill the process instead of being swallowed.
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
+
 	if outputMode == output.OutputModeInteractive {
-		collectResults := *outputPath != ""
+		collectResults := *outputPath != "" || *reportPath != ""
 		// Run TUI for Interactive mode
 		resultChannel := make(chan *speedtester.Result, len(allProxies))
 		resultsDone := make(chan struct{})
@@ -125,7 +242,7 @@ func main() {
 
 		// Start testing in goroutine to send results to channel
 		go func() {
-			speedTester.TestProxies(allProxies, func(result *speedtester.Result) {
+			speedTester.TestProxies(ctx, allProxies, func(result *speedtester.Result) {
 				if collectResults {
 					results = append(results, result)
 				}
@@ -140,7 +257,7 @@ func main() {
 			go func() {
 				<-resultsDone
 				results = output.SortResults(results, effectiveMode)
-				saveResult <- saveConfig(results, effectiveMode)
+				saveResult <- saveConfig(results, effectiveMode, renamer, nameCapture, exporter)
 			}()
 		}
 
@@ -149,9 +266,12 @@ func main() {
 			tui.NewTUIModel(effectiveMode, len(allProxies), resultChannel),
 			tea.WithAltScreen(),
 			tea.WithMouseAllMotion(),
+			tea.WithContext(ctx),
 		)
-		if _, err := p.Run(); err != nil {
-			log.Fatalln("TUI failed: %s", err)
+		_, err := p.Run()
+		cancel()
+		if err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+			log.Fatalf("TUI failed: %s", err)
 		}
 
 		if !collectResults {
@@ -160,38 +280,105 @@ func main() {
 
 		err = <-saveResult
 		if err != nil {
-			log.Fatalln("save config file failed: %s", err)
+			log.Fatalf("save config file failed: %s", err)
 		}
-		fmt.Printf("\nsave config file to: %s\n", *outputPath)
+		printSaved(os.Stdout)
 		return
 	}
 
 	// TSV mode: collect results synchronously
-	speedTester.TestProxies(allProxies, func(result *speedtester.Result) {
+	speedTester.TestProxies(ctx, allProxies, func(result *speedtester.Result) {
 		results = append(results, result)
 
-		if tsvWriter != nil {
-			if err := tsvWriter.WriteRow(result, len(results)-1); err != nil {
-				log.Printf("write TSV row failed: %s", err)
+		if resultWriter != nil {
+			if err := resultWriter.WriteRow(result, len(results)-1); err != nil {
+				log.Printf("write %s row failed: %s", format, err)
 			}
 		}
 	})
+	if resultWriter != nil {
+		if err := resultWriter.Close(); err != nil {
+			log.Printf("write %s results failed: %s", format, err)
+		}
+	}
 
 	results = output.SortResults(results, effectiveMode)
 
+	if *outputPath != "" || *reportPath != "" {
+		err = saveConfig(results, effectiveMode, renamer, nameCapture, exporter)
+		if err != nil {
+			log.Fatalf("save config file failed: %s", err)
+		}
+		// 指定 -format 时 stdout 只输出结果，提示信息写到 stderr
+		notice := os.Stdout
+		if format != output.FormatAuto {
+			notice = os.Stderr
+		}
+		printSaved(notice)
+	}
+}
+
+func printSaved(w io.Writer) {
 	if *outputPath != "" {
-		err = saveConfig(results, effectiveMode)
+		fmt.Fprintf(w, "\nsave config file to: %s\n", *outputPath)
+	}
+	if *reportPath != "" {
+		fmt.Fprintf(w, "save report to: %s\n", *reportPath)
+	}
+}
+
+// newLocator picks the GeoIP backend: a local mmdb file when -geoip-db is set, ip-api.com otherwise.
+// ip-api.com results are cached on disk; the returned function saves the cache and closes files.
+func newLocator() (ip.Locator, func(), error) {
+	if *geoipDB != "" {
+		locator, err := ip.OpenMMDBLocator(*geoipDB, *geoipASNDB)
 		if err != nil {
-			log.Fatalln("save config file failed: %s", err)
+			return nil, nil, err
 		}
-		fmt.Printf("\nsave config file to: %s\n", *outputPath)
+		return locator, func() { locator.Close() }, nil
+	}
+	if *geoipCache == "" {
+		return ip.NewIPAPILocator(nil), func() {}, nil
 	}
+	locator := ip.NewCachedLocator(ip.NewIPAPILocator(nil), *geoipCache, *geoipCacheTTL)
+	return locator, func() {
+		if err := locator.Save(); err != nil {
+			log.Printf("save geoip cache failed: %s", err)
+		}
+	}, nil
 }
 
-func saveConfig(results []*speedtester.Result, mode speedtester.SpeedMode) error {
+// proxyExporter encodes the output file in the -output-format format.
+type proxyExporter struct {
+	format convert.ExportFormat
+	group  convert.SingBoxGroup
+}
+
+func (e *proxyExporter) export(proxies []map[string]any) ([]byte, error) {
+	switch e.format {
+	case convert.ExportSingBox:
+		data, skipped, err := convert.SingBoxConfig(proxies, e.group)
+		for _, node := range skipped {
+			log.Printf("skip node %q in sing-box output: %s", node.Name, node.Err)
+		}
+		return data, err
+	case convert.ExportURI, convert.ExportBase64:
+		data, skipped := convert.ShareLinks(proxies, e.format == convert.ExportBase64)
+		for _, node := range skipped {
+			log.Printf("skip node %q in share link output: %s", node.Name, node.Err)
+		}
+		return data, nil
+	default:
+		return yaml.Marshal(&speedtester.RawConfig{
+			Proxies: proxies,
+		})
+	}
+}
+
+func saveConfig(results []*speedtester.Result, mode speedtester.SpeedMode, renamer *ip.Renamer, nameCapture *ip.NameCapture, exporter *proxyExporter) error {
 	proxies := make([]map[string]any, 0)
-	nameCount := make(map[string]int) // Track name usage to avoid duplicates
 
+	kept := make([]*speedtester.Result, 0, len(results))
 	for _, result := range results {
 		if *maxLatency > 0 && result.Latency > *maxLatency {
 			continue
@@ -199,62 +386,134 @@ func saveConfig(results []*speedtester.Result, mode speedtester.SpeedMode) error
 		if *maxPacketLoss >= 0 && result.PacketLoss > *maxPacketLoss {
 			continue
 		}
+		if *maxLoadedLatency > 0 && result.LoadedLatency > *maxLoadedLatency {
+			continue
+		}
+		if *maxBufferbloat > 0 && result.BufferbloatDelta > *maxBufferbloat {
+			continue
+		}
+		// 内容或请求头被篡改的节点不输出
+		if result.IntegrityError != "" {
+			continue
+		}
 		// 仅在实际测过下载时按下载速度过滤（fast 模式不测下载，DownloadSpeed 恒为 0）
-		if !mode.IsFast() && *downloadSize > 0 && *minDownloadSpeed > 0 && result.DownloadSpeed < *minDownloadSpeed*1024*1024 {
+		if !mode.IsFast() && (*downloadSize > 0 || *testDuration > 0) && *minDownloadSpeed > 0 && result.DownloadSpeed < *minDownloadSpeed*1024*1024 {
 			continue
 		}
 		if mode.UploadEnabled() && *minUploadSpeed > 0 && result.UploadSpeed < *minUploadSpeed*1024*1024 {
 			continue
 		}
-
-		proxyConfig := result.ProxyConfig
-		if proxyConfig["name"] == nil || proxyConfig["server"] == nil {
+		if result.ProxyConfig["name"] == nil || result.ProxyConfig["server"] == nil {
 			continue
 		}
+		kept = append(kept, result)
+	}
+
+	// 一次性批量查询所有节点的地区，避免逐个请求触发频率限制；报告包含全部节点，因此查询全部结果
+	var locations map[string]*ip.IPLocation
+	if *renameNodes || *reportPath != "" {
+		locator, closeLocator, err := newLocator()
+		if err != nil {
+			return err
+		}
+		located := kept
+		if *reportPath != "" {
+			located = results
+		}
+		hosts := make([]string, 0, len(located))
+		for _, result := range located {
+			hosts = append(hosts, result.LocationIP())
+		}
+		locations, err = ip.LocateAll(locator, hosts)
+		if err != nil {
+			log.Printf("locate some nodes failed: %s", err)
+		}
+		closeLocator()
+	}
+
+	for _, result := range kept {
+		proxyConfig := result.ProxyConfig
 		if *renameNodes {
-			location, err := ip.GetIPLocation(proxyConfig["server"].(string))
-			if err != nil || location.CountryCode == "" {
+			// 优先使用出口 IP 定位，未探测到时退回入口地址
+			location := locations[result.LocationIP()]
+			if location == nil || location.CountryCode == "" {
 				proxies = append(proxies, proxyConfig)
 				continue
 			}
-			name, err := ip.GenerateNodeNameFromTemplate(*renameTemplate, location.CountryCode, result.Latency, result.DownloadSpeed, result.UploadSpeed, nameCount)
-			if err != nil {
-				log.Printf("rename template parse error: %s, use default name", err)
-				name = ip.GenerateNodeName(location.CountryCode, result.Latency, result.DownloadSpeed, result.UploadSpeed, nameCount)
-			}
-			proxyConfig["name"] = name
+			// ProxyName 带有 "[provider] " 前缀，捕获需使用配置中的原始名称
+			originalName := fmt.Sprint(proxyConfig["name"])
+			proxyConfig["name"] = renamer.Name(ip.NodeInfo{
+				Location:      location,
+				Type:          result.ProxyType,
+				OriginalName:  originalName,
+				Address:       fmt.Sprintf("%v:%v", proxyConfig["server"], proxyConfig["port"]),
+				Provider:      result.Provider,
+				ExitIP:        result.ExitIP,
+				Latency:       result.Latency,
+				Jitter:        result.Jitter,
+				PacketLoss:    result.PacketLoss,
+				DownloadSpeed: result.DownloadSpeed,
+				UploadSpeed:   result.UploadSpeed,
+				Capture:       nameCapture.Extract(originalName),
+			})
 		}
 		proxies = append(proxies, proxyConfig)
 	}
 
-	config := &speedtester.RawConfig{
-		Proxies: proxies,
+	// 未能定位的节点保留原名称，可能与重命名后的节点重名，而 mihomo 不接受重名节点
+	names := make([]string, len(proxies))
+	for i, proxyConfig := range proxies {
+		names[i] = fmt.Sprint(proxyConfig["name"])
 	}
-	yamlData, err := yaml.Marshal(config)
-	if err != nil {
-		return err
+	for i, name := range ip.UniqueNames(names) {
+		proxies[i]["name"] = name
 	}
 
-	if err := os.WriteFile(*outputPath, yamlData, 0o644); err != nil {
-		return err
+	gistFiles := make(map[string][]byte)
+	if *outputPath != "" {
+		outputData, err := exporter.export(proxies)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(*outputPath, outputData, 0o644); err != nil {
+			return err
+		}
+		outputFilename := filepath.Base(filepath.Clean(*outputPath))
+		gistFiles[outputFilename] = outputData
+
+		if *repoToken != "" && *repoAddress != "" {
+			uploader := gist.NewUploader(nil)
+			repositoryFilePath := strings.TrimSpace(*repoFilePath)
+			if repositoryFilePath == "" {
+				repositoryFilePath = outputFilename
+			}
+			if err := uploader.UpdateRepoFile(*repoToken, *repoAddress, repositoryFilePath, *repoBranch, outputData); err != nil {
+				log.Printf("update repo file failed: %s", err)
+			}
+		}
 	}
-	outputFilename := filepath.Base(filepath.Clean(*outputPath))
 
-	if *gistToken != "" && *gistAddress != "" {
-		uploader := gist.NewUploader(nil)
-		if err := uploader.UpdateFile(*gistToken, *gistAddress, outputFilename, yamlData); err != nil {
-			log.Printf("update gist failed: %s", err)
+	if *reportPath != "" {
+		var buf bytes.Buffer
+		err := report.Generate(&buf, results, report.Options{
+			Mode:      mode,
+			Version:   version,
+			Locations: locations,
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, buf.Bytes(), 0o644); err != nil {
+			return err
 		}
+		gistFiles[filepath.Base(filepath.Clean(*reportPath))] = buf.Bytes()
 	}
 
-	if *repoToken != "" && *repoAddress != "" {
+	if *gistToken != "" && *gistAddress != "" {
 		uploader := gist.NewUploader(nil)
-		repositoryFilePath := strings.TrimSpace(*repoFilePath)
-		if repositoryFilePath == "" {
-			repositoryFilePath = outputFilename
-		}
-		if err := uploader.UpdateRepoFile(*repoToken, *repoAddress, repositoryFilePath, *repoBranch, yamlData); err != nil {
-			log.Printf("update repo file failed: %s", err)
+		if err := uploader.UpdateFiles(*gistToken, *gistAddress, gistFiles); err != nil {
+			log.Printf("update gist failed: %s", err)
 		}
 	}
 
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+// CSVWriter writes RFC 4180 CSV with the same columns as TSVWriter. Fields containing
+// commas, quotes or line breaks are quoted, so node names survive intact.
+type CSVWriter struct {
+	writer *csv.Writer
+	mode   speedtester.SpeedMode
+}
+
+// NewCSVWriter creates a new CSV writer and writes the header immediately
+func NewCSVWriter(output io.Writer, mode speedtester.SpeedMode) (*CSVWriter, error) {
+	w := &CSVWriter{
+		writer: csv.NewWriter(output),
+		mode:   mode,
+	}
+	if err := w.write(GetTSVHeaders(mode)); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return w, nil
+}
+
+// WriteRow writes a single result row and flushes it, so rows stream as results complete
+func (w *CSVWriter) WriteRow(result *speedtester.Result, index int) error {
+	if result == nil {
+		return errors.New("cannot write nil result")
+	}
+	if err := w.write(FormatTSVRow(result, w.mode, index)); err != nil {
+		return fmt.Errorf("write row for proxy %q (index %d) failed: %w", result.ProxyName, index, err)
+	}
+	return nil
+}
+
+func (w *CSVWriter) write(record []string) error {
+	if err := w.writer.Write(record); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Close does nothing; every row is flushed when written.
+func (w *CSVWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+func TestCSVWriterQuotesFields(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, speedtester.SpeedModeFast)
+	if err != nil {
+		t.Fatalf("create CSV writer failed: %v", err)
+	}
+	result := &speedtester.Result{ProxyName: `🇭🇰 HK, "IPLC" 01`, ProxyType: "Trojan", Latency: 100 * time.Millisecond}
+	if err := w.WriteRow(result, 0); err != nil {
+		t.Fatalf("write row failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected valid CSV, got %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and one row, got %d records", len(records))
+	}
+	if len(records[0]) != len(GetTSVHeaders(speedtester.SpeedModeFast)) {
+		t.Fatalf("expected TSV columns, got %v", records[0])
+	}
+	if records[1][1] != result.ProxyName || records[1][3] != "100ms" {
+		t.Fatalf("expected the name to round-trip, got %v", records[1])
+	}
+}
+
+func TestCSVWriterNilResult(t *testing.T) {
+	w, err := NewCSVWriter(&bytes.Buffer{}, speedtester.SpeedModeFast)
+	if err != nil {
+		t.Fatalf("create CSV writer failed: %v", err)
+	}
+	if err := w.WriteRow(nil, 0); err == nil {
+		t.Fatalf("expected an error for a nil result")
+	}
+}
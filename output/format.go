@@ -63,6 +63,32 @@ func FormatRow(result *speedtester.Result, mode speedtester.SpeedMode, index int
 	return row
 }
 
+// GetTSVHeaders returns GetHeaders plus the latency distribution and phase timing columns that only TSV output carries.
+func GetTSVHeaders(mode speedtester.SpeedMode) []string {
+	headers := append(GetHeaders(mode), "最小延迟", "延迟中位数", "P90延迟", "P99延迟", "代理握手", "TLS握手", "首字节")
+	if !mode.IsFast() {
+		headers = append(headers, "传输耗时")
+	}
+	return headers
+}
+
+// FormatTSVRow formats a row matching GetTSVHeaders.
+func FormatTSVRow(result *speedtester.Result, mode speedtester.SpeedMode, index int) []string {
+	row := append(FormatRow(result, mode, index),
+		result.FormatLatencyMin(),
+		result.FormatLatencyMedian(),
+		result.FormatLatencyP90(),
+		result.FormatLatencyP99(),
+		result.FormatProxyDialTime(),
+		result.FormatTLSHandshakeTime(),
+		result.FormatTTFB(),
+	)
+	if !mode.IsFast() {
+		row = append(row, result.FormatTransferTime())
+	}
+	return row
+}
+
 // SortResults sorts results based on speed mode.
 // fast: latency ascending (lower is better)
 // download/full: download speed descending (higher is better)
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+// JSONWriter writes results as ResultRecords. In lines mode every result is written as one
+// JSON object per line as soon as it completes; otherwise results are collected and written
+// as a single JSON array by Close.
+type JSONWriter struct {
+	output  io.Writer
+	mode    speedtester.SpeedMode
+	run     RunInfo
+	lines   bool
+	records []ResultRecord
+}
+
+// NewJSONWriter creates a writer producing one JSON array once all results are written.
+func NewJSONWriter(output io.Writer, mode speedtester.SpeedMode, run RunInfo) *JSONWriter {
+	return &JSONWriter{output: output, mode: mode, run: run, records: make([]ResultRecord, 0)}
+}
+
+// NewJSONLWriter creates a writer streaming one JSON object per line.
+func NewJSONLWriter(output io.Writer, mode speedtester.SpeedMode, run RunInfo) *JSONWriter {
+	w := NewJSONWriter(output, mode, run)
+	w.lines = true
+	return w
+}
+
+// WriteRow records a single result; in lines mode it is written immediately.
+func (w *JSONWriter) WriteRow(result *speedtester.Result, index int) error {
+	if result == nil {
+		return errors.New("cannot write nil result")
+	}
+	record := NewResultRecord(result, w.mode, index, w.run)
+	if !w.lines {
+		w.records = append(w.records, record)
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode result for proxy %q failed: %w", result.ProxyName, err)
+	}
+	if _, err := w.output.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write row for proxy %q (index %d) failed: %w", result.ProxyName, index, err)
+	}
+	return nil
+}
+
+// Close writes the collected array. It does nothing in lines mode.
+func (w *JSONWriter) Close() error {
+	if w.lines {
+		return nil
+	}
+	data, err := json.MarshalIndent(w.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode results failed: %w", err)
+	}
+	if _, err := w.output.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write results failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+func testRunInfo() RunInfo {
+	return RunInfo{
+		Version:   "v1.0.0",
+		Commit:    "abc123",
+		StartedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Parameters: NewTestParameters(&speedtester.Config{
+			Mode:         speedtester.SpeedModeFull,
+			DownloadSize: 1024,
+			Timeout:      5 * time.Second,
+			ConfigPaths:  "https://example.com/sub?token=secret",
+		}),
+	}
+}
+
+func TestJSONLWriterStreamsRecords(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf, speedtester.SpeedModeFull, testRunInfo())
+
+	result := &speedtester.Result{
+		ProxyName:     "HK 01",
+		ProxyType:     "Trojan",
+		ProxyConfig:   map[string]any{"server": "hk.example.com", "password": "secret"},
+		Latency:       1500 * time.Microsecond,
+		PacketLoss:    2.5,
+		DownloadSize:  2048,
+		DownloadTime:  2 * time.Second,
+		DownloadSpeed: 1024,
+		DownloadStreams: []speedtester.StreamResult{
+			{Stream: 1, Bytes: 2048, Duration: 2 * time.Second, Speed: 1024},
+		},
+		UploadError: "upload failed: EOF",
+		TestedAt:    time.Date(2024, 1, 2, 3, 5, 0, 0, time.UTC),
+	}
+	if err := w.WriteRow(result, 0); err != nil {
+		t.Fatalf("write row failed: %v", err)
+	}
+	if err := w.WriteRow(result, 1); err != nil {
+		t.Fatalf("write row failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per result, got %d: %s", len(lines), buf.String())
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Fatalf("expected proxy credentials and config paths to stay out of the output: %s", lines[0])
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("decode line failed: %v", err)
+	}
+	latency := record["latency"].(map[string]any)
+	if latency["average_ms"] != 1.5 || latency["packet_loss"] != 2.5 {
+		t.Fatalf("expected latency in milliseconds, got %v", latency)
+	}
+	download := record["download"].(map[string]any)
+	if download["speed"] != float64(1024) || download["duration_ms"] != float64(2000) {
+		t.Fatalf("expected speed in bytes/s and duration in ms, got %v", download)
+	}
+	if streams := download["streams"].([]any); len(streams) != 1 {
+		t.Fatalf("expected per-stream results, got %v", streams)
+	}
+	if upload := record["upload"].(map[string]any); upload["error"] != "upload failed: EOF" {
+		t.Fatalf("expected upload error, got %v", upload)
+	}
+	if record["server"] != "hk.example.com" || record["tested_at"] != "2024-01-02T03:05:00Z" || record["index"] != float64(1) {
+		t.Fatalf("unexpected record metadata: %v", record)
+	}
+	run := record["run"].(map[string]any)
+	parameters := run["parameters"].(map[string]any)
+	if run["version"] != "v1.0.0" || parameters["timeout_ms"] != float64(5000) || parameters["mode"] != "full" {
+		t.Fatalf("expected run info with test parameters, got %v", run)
+	}
+	if _, ok := record["udp"]; ok {
+		t.Fatalf("expected untested sections to be omitted, got %v", record["udp"])
+	}
+}
+
+func TestJSONWriterWritesArrayOnClose(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf, speedtester.SpeedModeFast, testRunInfo())
+	for i, name := range []string{"a", "b"} {
+		if err := w.WriteRow(&speedtester.Result{ProxyName: name, Latency: 10 * time.Millisecond}, i); err != nil {
+			t.Fatalf("write row failed: %v", err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected nothing to be written before Close, got %q", buf.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	var records []ResultRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("decode array failed: %v", err)
+	}
+	if len(records) != 2 || records[1].Name != "b" {
+		t.Fatalf("expected both results in order, got %+v", records)
+	}
+	if records[0].Download != nil || records[0].Upload != nil {
+		t.Fatalf("expected fast mode records without transfer sections, got %+v", records[0])
+	}
+}
+
+func TestJSONWriterRejectsNilResult(t *testing.T) {
+	w := NewJSONLWriter(&bytes.Buffer{}, speedtester.SpeedModeFast, RunInfo{})
+	if err := w.WriteRow(nil, 0); err == nil {
+		t.Fatalf("expected an error for a nil result")
+	}
+}
+
+func TestJSONWriterEmptyRunWritesEmptyArray(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewJSONWriter(&buf, speedtester.SpeedModeFast, RunInfo{}).Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("expected an empty array, got %q", buf.String())
+	}
+}
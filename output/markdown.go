@@ -0,0 +1,66 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+// markdownEscaper keeps cell content from breaking the table: pipes end a cell and line
+// breaks end the row.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "\r\n", "<br>", "\n", "<br>", "\r", "<br>")
+
+// MarkdownWriter writes a GitHub-flavoured Markdown table with the same columns as TSVWriter.
+// The header and delimiter rows are written on creation, then rows stream as they complete.
+type MarkdownWriter struct {
+	output io.Writer
+	mode   speedtester.SpeedMode
+}
+
+// NewMarkdownWriter creates a new Markdown writer and writes the header immediately
+func NewMarkdownWriter(output io.Writer, mode speedtester.SpeedMode) (*MarkdownWriter, error) {
+	w := &MarkdownWriter{
+		output: output,
+		mode:   mode,
+	}
+	headers := GetTSVHeaders(mode)
+	delimiters := make([]string, len(headers))
+	for i := range delimiters {
+		delimiters[i] = "---"
+	}
+	if err := w.writeRow(headers); err != nil {
+		return nil, fmt.Errorf("failed to write Markdown header: %w", err)
+	}
+	if err := w.writeRow(delimiters); err != nil {
+		return nil, fmt.Errorf("failed to write Markdown header: %w", err)
+	}
+	return w, nil
+}
+
+// WriteRow writes a single result row as a table row
+func (w *MarkdownWriter) WriteRow(result *speedtester.Result, index int) error {
+	if result == nil {
+		return errors.New("cannot write nil result")
+	}
+	if err := w.writeRow(FormatTSVRow(result, w.mode, index)); err != nil {
+		return fmt.Errorf("write row for proxy %q (index %d) failed: %w", result.ProxyName, index, err)
+	}
+	return nil
+}
+
+func (w *MarkdownWriter) writeRow(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = markdownEscaper.Replace(cell)
+	}
+	_, err := io.WriteString(w.output, "| "+strings.Join(escaped, " | ")+" |\n")
+	return err
+}
+
+// Close does nothing; every row is written as it completes.
+func (w *MarkdownWriter) Close() error {
+	return nil
+}
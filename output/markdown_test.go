@@ -0,0 +1,36 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+func TestMarkdownWriterTable(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewMarkdownWriter(&buf, speedtester.SpeedModeFast)
+	if err != nil {
+		t.Fatalf("create Markdown writer failed: %v", err)
+	}
+	result := &speedtester.Result{ProxyName: "HK | IPLC\n01", ProxyType: "Trojan", Latency: 100 * time.Millisecond}
+	if err := w.WriteRow(result, 0); err != nil {
+		t.Fatalf("write row failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header, delimiter and one row, got %q", buf.String())
+	}
+	if lines[0] != "| 序号 | 节点名称 | 类型 | 延迟 | 最小延迟 | 延迟中位数 | P90延迟 | P99延迟 | 代理握手 | TLS握手 | 首字节 |" {
+		t.Fatalf("unexpected header %q", lines[0])
+	}
+	if strings.Count(lines[1], "---") != len(GetTSVHeaders(speedtester.SpeedModeFast)) {
+		t.Fatalf("unexpected delimiter row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], `| 1. | HK \| IPLC<br>01 | Trojan | 100ms |`) {
+		t.Fatalf("expected escaped cells, got %q", lines[2])
+	}
+}
@@ -1,7 +1,9 @@
 package output
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"golang.org/x/term"
 )
@@ -10,7 +12,7 @@ import (
 type OutputMode int
 
 const (
-	// OutputModeTSV outputs tab-separated values without ANSI colors
+	// OutputModeTSV outputs machine-readable rows without ANSI colors: TSV, or the selected Format
 	OutputModeTSV OutputMode = iota
 	// OutputModeInteractive outputs interactive UI (currently tablewriter, future TUI)
 	OutputModeInteractive
@@ -34,3 +36,45 @@ func DetermineOutputMode(isTerminal IsTerminal) OutputMode {
 func IsTerminalFile(file *os.File) bool {
 	return term.IsTerminal(int(file.Fd()))
 }
+
+// Format is the result format selected with -format.
+type Format string
+
+const (
+	// FormatAuto uses the TUI on a terminal and TSV otherwise.
+	FormatAuto     Format = "auto"
+	FormatTSV      Format = "tsv"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+	FormatJSONL    Format = "jsonl"
+)
+
+func ParseFormat(value string) (Format, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch Format(normalized) {
+	case "", FormatAuto:
+		return FormatAuto, nil
+	case FormatTSV:
+		return FormatTSV, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatJSONL:
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q", value)
+	}
+}
+
+// ResolveOutputMode is DetermineOutputMode for a -format value: an explicit format always
+// writes machine-readable output, even to a terminal.
+func ResolveOutputMode(format Format, isTerminal IsTerminal) OutputMode {
+	if format != FormatAuto {
+		return OutputModeTSV
+	}
+	return DetermineOutputMode(isTerminal)
+}
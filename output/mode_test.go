@@ -53,3 +53,34 @@ func TestOutputModeValues(t *testing.T) {
 		t.Errorf("OutputModeInteractive should be 1, got %d", OutputModeInteractive)
 	}
 }
+
+func TestParseFormat(t *testing.T) {
+	tests := map[string]Format{
+		"":      FormatAuto,
+		"auto":  FormatAuto,
+		" TSV ": FormatTSV,
+		"csv":   FormatCSV,
+		"md":    FormatMarkdown,
+		"json":  FormatJSON,
+		"JSONL": FormatJSONL,
+	}
+	for value, expected := range tests {
+		format, err := ParseFormat(value)
+		if err != nil || format != expected {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", value, format, err, expected)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected an unsupported format to be rejected")
+	}
+}
+
+func TestResolveOutputMode(t *testing.T) {
+	terminal := func(*os.File) bool { return true }
+	if mode := ResolveOutputMode(FormatAuto, terminal); mode != OutputModeInteractive {
+		t.Errorf("auto format on a terminal should be interactive, got %v", mode)
+	}
+	if mode := ResolveOutputMode(FormatJSON, terminal); mode != OutputModeTSV {
+		t.Errorf("an explicit format should bypass the TUI, got %v", mode)
+	}
+}
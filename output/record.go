@@ -0,0 +1,239 @@
+package output
+
+import (
+	"time"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+// RunInfo describes a test run. It is attached to every machine-readable record so each
+// record can be ingested on its own.
+type RunInfo struct {
+	Version    string         `json:"version"`
+	Commit     string         `json:"commit"`
+	StartedAt  time.Time      `json:"started_at"`
+	Parameters TestParameters `json:"parameters"`
+}
+
+// TestParameters are the settings a run was made with. Config paths are left out because
+// subscription URLs often carry access tokens.
+type TestParameters struct {
+	Mode                speedtester.SpeedMode      `json:"mode"`
+	ServerURL           string                     `json:"server_url"`
+	DownloadSize        int                        `json:"download_size"`
+	UploadSize          int                        `json:"upload_size"`
+	TestDurationMs      float64                    `json:"test_duration_ms"`
+	TimeoutMs           float64                    `json:"timeout_ms"`
+	Concurrent          int                        `json:"concurrent"`
+	TransferPolicy      speedtester.TransferPolicy `json:"transfer_policy"`
+	MinSuccessRatio     float64                    `json:"min_success_ratio"`
+	PingCount           int                        `json:"ping_count"`
+	PingIntervalMs      float64                    `json:"ping_interval_ms"`
+	PayloadType         speedtester.PayloadType    `json:"payload_type"`
+	VerifyPayload       bool                       `json:"verify_payload"`
+	ConcurrencyScaling  bool                       `json:"concurrency_scaling"`
+	ScalingStreams      []int                      `json:"scaling_streams"`
+	SustainedDurationMs float64                    `json:"sustained_duration_ms"`
+	UDPServer           string                     `json:"udp_server"`
+	ExitIPURL           string                     `json:"exit_ip_url"`
+}
+
+// NewTestParameters reads the parameters from a config that speedtester.New has normalized.
+func NewTestParameters(config *speedtester.Config) TestParameters {
+	return TestParameters{
+		Mode:                config.Mode,
+		ServerURL:           config.ServerURL,
+		DownloadSize:        config.DownloadSize,
+		UploadSize:          config.UploadSize,
+		TestDurationMs:      milliseconds(config.TestDuration),
+		TimeoutMs:           milliseconds(config.Timeout),
+		Concurrent:          config.Concurrent,
+		TransferPolicy:      config.TransferPolicy,
+		MinSuccessRatio:     config.MinSuccessRatio,
+		PingCount:           config.PingCount,
+		PingIntervalMs:      milliseconds(config.PingInterval),
+		PayloadType:         config.PayloadType,
+		VerifyPayload:       config.VerifyPayload,
+		ConcurrencyScaling:  config.ConcurrencyScaling,
+		ScalingStreams:      config.ScalingStreams,
+		SustainedDurationMs: milliseconds(config.SustainedDuration),
+		UDPServer:           config.UDPServer,
+		ExitIPURL:           config.ExitIPURL,
+	}
+}
+
+// ResultRecord is the machine-readable form of a speedtester.Result: durations are in
+// milliseconds, speeds in bytes/s and sizes in bytes. Sections that were not tested are omitted.
+type ResultRecord struct {
+	Index          int              `json:"index"`
+	Name           string           `json:"name"`
+	Type           string           `json:"type"`
+	Provider       string           `json:"provider"`
+	Server         string           `json:"server"`
+	ExitIP         string           `json:"exit_ip"`
+	ExitIPError    string           `json:"exit_ip_error"`
+	TestedAt       time.Time        `json:"tested_at"`
+	Latency        LatencyRecord    `json:"latency"`
+	Download       *TransferRecord  `json:"download,omitempty"`
+	Upload         *TransferRecord  `json:"upload,omitempty"`
+	UDP            *UDPRecord       `json:"udp,omitempty"`
+	Scaling        *ScalingRecord   `json:"scaling,omitempty"`
+	Sustained      *SustainedRecord `json:"sustained,omitempty"`
+	IntegrityError string           `json:"integrity_error"`
+	Run            RunInfo          `json:"run"`
+}
+
+type LatencyRecord struct {
+	AverageMs      float64 `json:"average_ms"`
+	MinMs          float64 `json:"min_ms"`
+	MedianMs       float64 `json:"median_ms"`
+	P90Ms          float64 `json:"p90_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+	JitterMs       float64 `json:"jitter_ms"`
+	PacketLoss     float64 `json:"packet_loss"` // percent
+	ProxyDialMs    float64 `json:"proxy_dial_ms"`
+	TLSHandshakeMs float64 `json:"tls_handshake_ms"`
+	TTFBMs         float64 `json:"ttfb_ms"`
+	LoadedMs       float64 `json:"loaded_ms"`
+	BufferbloatMs  float64 `json:"bufferbloat_ms"`
+	TransferMs     float64 `json:"transfer_ms"`
+}
+
+type TransferRecord struct {
+	Bytes       float64        `json:"bytes"`
+	DurationMs  float64        `json:"duration_ms"`
+	Speed       float64        `json:"speed"`
+	PeakSpeed   float64        `json:"peak_speed"`
+	SteadySpeed float64        `json:"steady_speed"`
+	StallCount  int            `json:"stall_count"`
+	StallMs     float64        `json:"stall_ms"`
+	Samples     []float64      `json:"samples"`
+	SampleMs    float64        `json:"sample_ms"`
+	Streams     []StreamRecord `json:"streams"`
+	Error       string         `json:"error"`
+}
+
+type StreamRecord struct {
+	Stream     int     `json:"stream"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+	Speed      float64 `json:"speed"`
+	Error      string  `json:"error"`
+}
+
+type UDPRecord struct {
+	Supported  bool    `json:"supported"`
+	LatencyMs  float64 `json:"latency_ms"`
+	PacketLoss float64 `json:"packet_loss"` // percent
+	Error      string  `json:"error"`
+}
+
+type ScalingRecord struct {
+	SingleStreamSpeed float64                   `json:"single_stream_speed"`
+	BestStreamSpeed   float64                   `json:"best_stream_speed"`
+	BestStreamCount   int                       `json:"best_stream_count"`
+	Steps             []speedtester.ScalingStep `json:"steps"`
+}
+
+type SustainedRecord struct {
+	InitialSpeed    float64 `json:"initial_speed"`
+	FinalSpeed      float64 `json:"final_speed"`
+	Throttled       bool    `json:"throttled"`
+	ThrottleBytes   int64   `json:"throttle_bytes"`
+	ThrottleAfterMs float64 `json:"throttle_after_ms"`
+	Error           string  `json:"error"`
+}
+
+// NewResultRecord converts result, the index-th result of run, for the given speed mode.
+func NewResultRecord(result *speedtester.Result, mode speedtester.SpeedMode, index int, run RunInfo) ResultRecord {
+	record := ResultRecord{
+		Index:       index + 1,
+		Name:        result.ProxyName,
+		Type:        result.ProxyType,
+		Provider:    result.Provider,
+		Server:      result.EntryIP(),
+		ExitIP:      result.ExitIP,
+		ExitIPError: result.ExitIPError,
+		TestedAt:    result.TestedAt,
+		Latency: LatencyRecord{
+			AverageMs:      milliseconds(result.Latency),
+			MinMs:          milliseconds(result.LatencyMin),
+			MedianMs:       milliseconds(result.LatencyMedian),
+			P90Ms:          milliseconds(result.LatencyP90),
+			P99Ms:          milliseconds(result.LatencyP99),
+			JitterMs:       milliseconds(result.Jitter),
+			PacketLoss:     result.PacketLoss,
+			ProxyDialMs:    milliseconds(result.ProxyDialTime),
+			TLSHandshakeMs: milliseconds(result.TLSHandshakeTime),
+			TTFBMs:         milliseconds(result.TTFB),
+			LoadedMs:       milliseconds(result.LoadedLatency),
+			BufferbloatMs:  milliseconds(result.BufferbloatDelta),
+			TransferMs:     milliseconds(result.TransferTime),
+		},
+		IntegrityError: result.IntegrityError,
+		Run:            run,
+	}
+	if !mode.IsFast() {
+		record.Download = newTransferRecord(result.DownloadSize, result.DownloadTime, result.DownloadSpeed, result.DownloadError, result.DownloadStats, result.DownloadStreams)
+	}
+	if mode.UploadEnabled() {
+		record.Upload = newTransferRecord(result.UploadSize, result.UploadTime, result.UploadSpeed, result.UploadError, result.UploadStats, result.UploadStreams)
+	}
+	if result.UDPTested {
+		record.UDP = &UDPRecord{
+			Supported:  result.UDPSupported,
+			LatencyMs:  milliseconds(result.UDPLatency),
+			PacketLoss: result.UDPPacketLoss,
+			Error:      result.UDPError,
+		}
+	}
+	if len(result.ScalingSteps) > 0 {
+		record.Scaling = &ScalingRecord{
+			SingleStreamSpeed: result.SingleStreamSpeed,
+			BestStreamSpeed:   result.BestStreamSpeed,
+			BestStreamCount:   result.BestStreamCount,
+			Steps:             result.ScalingSteps,
+		}
+	}
+	if result.SustainedTested {
+		record.Sustained = &SustainedRecord{
+			InitialSpeed:    result.SustainedInitialSpeed,
+			FinalSpeed:      result.SustainedFinalSpeed,
+			Throttled:       result.Throttled,
+			ThrottleBytes:   result.ThrottleBytes,
+			ThrottleAfterMs: milliseconds(result.ThrottleAfter),
+			Error:           result.SustainedError,
+		}
+	}
+	return record
+}
+
+func newTransferRecord(size float64, duration time.Duration, speed float64, transferError string, stats speedtester.ThroughputStats, streams []speedtester.StreamResult) *TransferRecord {
+	record := &TransferRecord{
+		Bytes:       size,
+		DurationMs:  milliseconds(duration),
+		Speed:       speed,
+		PeakSpeed:   stats.PeakSpeed,
+		SteadySpeed: stats.SteadySpeed,
+		StallCount:  stats.StallCount,
+		StallMs:     milliseconds(stats.StallDuration),
+		Samples:     stats.Samples,
+		SampleMs:    milliseconds(stats.SampleInterval),
+		Streams:     make([]StreamRecord, 0, len(streams)),
+		Error:       transferError,
+	}
+	for _, stream := range streams {
+		record.Streams = append(record.Streams, StreamRecord{
+			Stream:     stream.Stream,
+			Bytes:      stream.Bytes,
+			DurationMs: milliseconds(stream.Duration),
+			Speed:      stream.Speed,
+			Error:      stream.Error,
+		})
+	}
+	return record
+}
+
+func milliseconds(value time.Duration) float64 {
+	return float64(value) / float64(time.Millisecond)
+}
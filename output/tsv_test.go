@@ -18,17 +18,17 @@ func TestNewTSVWriter(t *testing.T) {
 		{
 			name:           "fast mode header",
 			mode:           speedtester.SpeedModeFast,
-			expectedHeader: "序号\t节点名称\t类型\t延迟\n",
+			expectedHeader: "序号\t节点名称\t类型\t延迟\t最小延迟\t延迟中位数\tP90延迟\tP99延迟\t代理握手\tTLS握手\t首字节\n",
 		},
 		{
 			name:           "download-only mode header",
 			mode:           speedtester.SpeedModeDownload,
-			expectedHeader: "序号\t节点名称\t类型\t延迟\t抖动\t丢包率\t下载速度\n",
+			expectedHeader: "序号\t节点名称\t类型\t延迟\t抖动\t丢包率\t下载速度\t最小延迟\t延迟中位数\tP90延迟\tP99延迟\t代理握手\tTLS握手\t首字节\t传输耗时\n",
 		},
 		{
 			name:           "upload-enabled mode header",
 			mode:           speedtester.SpeedModeFull,
-			expectedHeader: "序号\t节点名称\t类型\t延迟\t抖动\t丢包率\t下载速度\t上传速度\n",
+			expectedHeader: "序号\t节点名称\t类型\t延迟\t抖动\t丢包率\t下载速度\t上传速度\t最小延迟\t延迟中位数\tP90延迟\tP99延迟\t代理握手\tTLS握手\t首字节\t传输耗时\n",
 		},
 	}
 
@@ -68,7 +68,7 @@ func TestTSVWriter_WriteRow(t *testing.T) {
 				Latency:   500 * time.Millisecond,
 			},
 			index:       0,
-			expectedRow: "1.\tTest Proxy\tTrojan\t500ms\n",
+			expectedRow: "1.\tTest Proxy\tTrojan\t500ms\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
 		},
 		{
 			name: "download-only mode row",
@@ -83,7 +83,7 @@ func TestTSVWriter_WriteRow(t *testing.T) {
 				UploadSpeed:   5 * 1024 * 1024,
 			},
 			index:       1,
-			expectedRow: "2.\tTest Proxy\tTrojan\t500ms\t50ms\t5.0%\t10.00MB/s\n",
+			expectedRow: "2.\tTest Proxy\tTrojan\t500ms\t50ms\t5.0%\t10.00MB/s\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
 		},
 		{
 			name: "row with N/A values",
@@ -98,7 +98,28 @@ func TestTSVWriter_WriteRow(t *testing.T) {
 				UploadSpeed:   0,
 			},
 			index:       2,
-			expectedRow: "3.\tFailed Proxy\tShadowsocks\tN/A\tN/A\t100.0%\tN/A\n",
+			expectedRow: "3.\tFailed Proxy\tShadowsocks\tN/A\tN/A\t100.0%\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
+		},
+		{
+			name: "download-only row with latency distribution and phase timings",
+			mode: speedtester.SpeedModeDownload,
+			result: &speedtester.Result{
+				ProxyName:        "Traced Proxy",
+				ProxyType:        "Vless",
+				Latency:          250 * time.Millisecond,
+				LatencyMin:       200 * time.Millisecond,
+				LatencyMedian:    240 * time.Millisecond,
+				LatencyP90:       310 * time.Millisecond,
+				LatencyP99:       330 * time.Millisecond,
+				Jitter:           5 * time.Millisecond,
+				DownloadSpeed:    2 * 1024 * 1024,
+				ProxyDialTime:    120 * time.Millisecond,
+				TLSHandshakeTime: 80 * time.Millisecond,
+				TTFB:             40 * time.Millisecond,
+				TransferTime:     3 * time.Second,
+			},
+			index:       4,
+			expectedRow: "5.\tTraced Proxy\tVless\t250ms\t5ms\t0.0%\t2.00MB/s\t200ms\t240ms\t310ms\t330ms\t120ms\t80ms\t40ms\t3000ms\n",
 		},
 		{
 			name: "upload-enabled row with errors",
@@ -113,7 +134,7 @@ func TestTSVWriter_WriteRow(t *testing.T) {
 				UploadError:   "upload failed: 500",
 			},
 			index:       3,
-			expectedRow: "4.\tError Proxy\tVmess\t300ms\t10ms\t2.0%\tdownload failed: timeout\tupload failed: 500\n",
+			expectedRow: "4.\tError Proxy\tVmess\t300ms\t10ms\t2.0%\tdownload failed: timeout\tupload failed: 500\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
 		},
 	}
 
@@ -163,7 +184,7 @@ func TestTSVWriter_WriteRows(t *testing.T) {
 					Latency:   200 * time.Millisecond,
 				},
 			},
-			expectedRows: "1.\tProxy 1\tTrojan\t100ms\n2.\tProxy 2\tShadowsocks\t200ms\n",
+			expectedRows: "1.\tProxy 1\tTrojan\t100ms\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n2.\tProxy 2\tShadowsocks\t200ms\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
 		},
 		{
 			name: "upload-enabled mode multiple rows",
@@ -188,7 +209,7 @@ func TestTSVWriter_WriteRows(t *testing.T) {
 					UploadSpeed:   8 * 1024 * 1024,
 				},
 			},
-			expectedRows: "1.\tProxy 1\tTrojan\t100ms\t10ms\t0.0%\t20.00MB/s\t10.00MB/s\n2.\tProxy 2\tShadowsocks\t200ms\t20ms\t5.0%\t15.00MB/s\t8.00MB/s\n",
+			expectedRows: "1.\tProxy 1\tTrojan\t100ms\t10ms\t0.0%\t20.00MB/s\t10.00MB/s\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n2.\tProxy 2\tShadowsocks\t200ms\t20ms\t5.0%\t15.00MB/s\t8.00MB/s\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\tN/A\n",
 		},
 	}
 
@@ -0,0 +1,65 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+// ResultWriter writes test results in one output format. Rows are written as results
+// complete; Close finishes the output once the run is over.
+type ResultWriter interface {
+	WriteRow(result *speedtester.Result, index int) error
+	Close() error
+}
+
+// NewResultWriter creates the writer for a format other than FormatAuto. Headers of table
+// formats are written immediately.
+func NewResultWriter(format Format, output io.Writer, mode speedtester.SpeedMode, run RunInfo) (ResultWriter, error) {
+	switch format {
+	case FormatAuto, FormatTSV:
+		return NewTSVWriter(output, mode)
+	case FormatCSV:
+		return NewCSVWriter(output, mode)
+	case FormatMarkdown:
+		return NewMarkdownWriter(output, mode)
+	case FormatJSON:
+		return NewJSONWriter(output, mode, run), nil
+	case FormatJSONL:
+		return NewJSONLWriter(output, mode, run), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// SortedWriter buffers results and writes them to the wrapped writer in SortResults order
+// when closed, instead of in the order they complete.
+type SortedWriter struct {
+	writer  ResultWriter
+	mode    speedtester.SpeedMode
+	results []*speedtester.Result
+}
+
+func NewSortedWriter(writer ResultWriter, mode speedtester.SpeedMode) *SortedWriter {
+	return &SortedWriter{writer: writer, mode: mode}
+}
+
+// WriteRow buffers result; the index is reassigned by sort order on Close.
+func (w *SortedWriter) WriteRow(result *speedtester.Result, index int) error {
+	if result == nil {
+		return errors.New("cannot write nil result")
+	}
+	w.results = append(w.results, result)
+	return nil
+}
+
+func (w *SortedWriter) Close() error {
+	for i, result := range SortResults(w.results, w.mode) {
+		if err := w.writer.WriteRow(result, i); err != nil {
+			return err
+		}
+	}
+	return w.writer.Close()
+}
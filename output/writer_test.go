@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+func TestNewResultWriterFormats(t *testing.T) {
+	tests := []struct {
+		format   Format
+		expected string
+	}{
+		{format: FormatAuto, expected: "序号\t"},
+		{format: FormatTSV, expected: "序号\t"},
+		{format: FormatCSV, expected: "序号,"},
+		{format: FormatMarkdown, expected: "| 序号 |"},
+		{format: FormatJSON, expected: ""},
+		{format: FormatJSONL, expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := NewResultWriter(tt.format, &buf, speedtester.SpeedModeFast, RunInfo{}); err != nil {
+				t.Fatalf("create writer failed: %v", err)
+			}
+			if !strings.HasPrefix(buf.String(), tt.expected) {
+				t.Fatalf("expected output to start with %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+	if _, err := NewResultWriter(Format("xml"), &bytes.Buffer{}, speedtester.SpeedModeFast, RunInfo{}); err == nil {
+		t.Fatalf("expected an unsupported format to be rejected")
+	}
+}
+
+func TestSortedWriterWritesOnClose(t *testing.T) {
+	var buf bytes.Buffer
+	csvWriter, err := NewCSVWriter(&buf, speedtester.SpeedModeFast)
+	if err != nil {
+		t.Fatalf("create CSV writer failed: %v", err)
+	}
+	header := buf.String()
+	w := NewSortedWriter(csvWriter, speedtester.SpeedModeFast)
+
+	for i, latency := range []time.Duration{300, 100, 200} {
+		result := &speedtester.Result{ProxyName: latency.String(), Latency: latency * time.Millisecond}
+		if err := w.WriteRow(result, i); err != nil {
+			t.Fatalf("write row failed: %v", err)
+		}
+	}
+	if buf.String() != header {
+		t.Fatalf("expected rows to be held back until Close, got %q", buf.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), header)), "\n")
+	expected := []string{"1.,100ns", "2.,200ns", "3.,300ns"}
+	for i, row := range rows {
+		if !strings.HasPrefix(row, expected[i]) {
+			t.Fatalf("row %d: expected sorted row starting with %q, got %q", i, expected[i], row)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package report renders test results as a single self-contained HTML page.
+package report
+
+import (
+	"cmp"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/faceair/clash-speedtest/ip"
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+//go:embed report.html
+var reportTemplate string
+
+var page = template.Must(template.New("report").Parse(reportTemplate))
+
+// unknownGroup labels nodes whose country could not be located.
+const unknownGroup = "Unknown"
+
+// latencyEdges and speedEdges are the upper bounds of the histogram buckets, in ms and MB/s.
+var (
+	latencyEdges = []float64{100, 200, 300, 500, 800, 1200}
+	speedEdges   = []float64{1, 5, 10, 20, 50, 100}
+)
+
+// Options describe the run a report is generated for.
+type Options struct {
+	Mode        speedtester.SpeedMode
+	Version     string
+	GeneratedAt time.Time
+	// Locations maps LocationIP of results to their geolocation; nodes missing from it are
+	// grouped as Unknown.
+	Locations map[string]*ip.IPLocation
+}
+
+type pageData struct {
+	Version          string
+	GeneratedAt      string
+	Mode             speedtester.SpeedMode
+	Total            int
+	Available        int
+	ShowDownload     bool
+	ShowUpload       bool
+	Nodes            []nodeRow
+	Countries        []summaryRow
+	Protocols        []summaryRow
+	LatencyHistogram []bucket
+	SpeedHistogram   []bucket
+}
+
+type nodeRow struct {
+	Name          string
+	Type          string
+	Country       string
+	Provider      string
+	Latency       string
+	LatencyMs     int64
+	Jitter        string
+	JitterMs      int64
+	PacketLoss    string
+	PacketLossPct float64
+	Download      string
+	DownloadSpeed float64
+	Upload        string
+	UploadSpeed   float64
+	Errors        string
+}
+
+type summaryRow struct {
+	Name           string
+	Count          int
+	Available      int
+	AverageLatency string
+	MedianDownload string
+	BestDownload   string
+}
+
+type bucket struct {
+	Label   string
+	Count   int
+	Percent float64 // bar width relative to the largest bucket
+}
+
+// Generate writes the report for results to w.
+func Generate(w io.Writer, results []*speedtester.Result, options Options) error {
+	if options.GeneratedAt.IsZero() {
+		options.GeneratedAt = time.Now()
+	}
+	mode := options.Mode
+	if mode == "" {
+		mode = speedtester.SpeedModeDownload
+	}
+	data := pageData{
+		Version:      options.Version,
+		GeneratedAt:  options.GeneratedAt.Format(time.RFC3339),
+		Mode:         mode,
+		Total:        len(results),
+		ShowDownload: !mode.IsFast(),
+		ShowUpload:   mode.UploadEnabled(),
+		Nodes:        make([]nodeRow, 0, len(results)),
+	}
+
+	countries := make(map[string][]*speedtester.Result)
+	protocols := make(map[string][]*speedtester.Result)
+	var latencies, speeds []float64
+	for _, result := range results {
+		country := countryOf(result, options.Locations)
+		countries[country] = append(countries[country], result)
+		protocols[result.ProxyType] = append(protocols[result.ProxyType], result)
+		if result.Latency > 0 {
+			data.Available++
+			latencies = append(latencies, float64(result.Latency.Milliseconds()))
+		}
+		if result.DownloadSpeed > 0 {
+			speeds = append(speeds, result.DownloadSpeed/(1024*1024))
+		}
+		data.Nodes = append(data.Nodes, nodeRow{
+			Name:          result.ProxyName,
+			Type:          result.ProxyType,
+			Country:       country,
+			Provider:      result.Provider,
+			Latency:       result.FormatLatency(),
+			LatencyMs:     result.Latency.Milliseconds(),
+			Jitter:        result.FormatJitter(),
+			JitterMs:      result.Jitter.Milliseconds(),
+			PacketLoss:    result.FormatPacketLoss(),
+			PacketLossPct: result.PacketLoss,
+			Download:      result.FormatDownloadSpeedValue(),
+			DownloadSpeed: result.DownloadSpeed,
+			Upload:        result.FormatUploadSpeedValue(),
+			UploadSpeed:   result.UploadSpeed,
+			Errors:        resultErrors(result),
+		})
+	}
+	data.Countries = summarize(countries)
+	data.Protocols = summarize(protocols)
+	data.LatencyHistogram = histogram(latencies, latencyEdges, "ms")
+	data.SpeedHistogram = histogram(speeds, speedEdges, "MB/s")
+
+	if err := page.Execute(w, data); err != nil {
+		return fmt.Errorf("render report failed: %w", err)
+	}
+	return nil
+}
+
+func countryOf(result *speedtester.Result, locations map[string]*ip.IPLocation) string {
+	location := locations[result.LocationIP()]
+	if location == nil || location.CountryCode == "" {
+		return unknownGroup
+	}
+	return strings.ToUpper(location.CountryCode)
+}
+
+// resultErrors joins every error recorded for result, labelled by the test it came from.
+func resultErrors(result *speedtester.Result) string {
+	var messages []string
+	for _, entry := range []struct{ label, message string }{
+		{"download", result.DownloadError},
+		{"upload", result.UploadError},
+		{"udp", result.UDPError},
+		{"sustained", result.SustainedError},
+		{"integrity", result.IntegrityError},
+		{"exit ip", result.ExitIPError},
+	} {
+		if entry.message != "" {
+			messages = append(messages, entry.label+": "+entry.message)
+		}
+	}
+	return strings.Join(messages, "\n")
+}
+
+// summarize aggregates groups of results, largest group first.
+func summarize(groups map[string][]*speedtester.Result) []summaryRow {
+	rows := make([]summaryRow, 0, len(groups))
+	for name, results := range groups {
+		row := summaryRow{Name: name, Count: len(results)}
+		var totalLatency time.Duration
+		var speeds []float64
+		for _, result := range results {
+			if result.Latency > 0 {
+				row.Available++
+				totalLatency += result.Latency
+			}
+			if result.DownloadSpeed > 0 {
+				speeds = append(speeds, result.DownloadSpeed)
+			}
+		}
+		row.AverageLatency = "N/A"
+		if row.Available > 0 {
+			row.AverageLatency = fmt.Sprintf("%dms", (totalLatency / time.Duration(row.Available)).Milliseconds())
+		}
+		row.MedianDownload, row.BestDownload = "N/A", "N/A"
+		if len(speeds) > 0 {
+			slices.Sort(speeds)
+			median := speeds[len(speeds)/2]
+			if len(speeds)%2 == 0 {
+				median = (speeds[len(speeds)/2-1] + median) / 2
+			}
+			row.MedianDownload = formatMBps(median)
+			row.BestDownload = formatMBps(speeds[len(speeds)-1])
+		}
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b summaryRow) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return rows
+}
+
+func formatMBps(bytesPerSecond float64) string {
+	return fmt.Sprintf("%.2fMB/s", bytesPerSecond/(1024*1024))
+}
+
+// histogram counts values into buckets bounded by edges, with a final open-ended bucket.
+func histogram(values []float64, edges []float64, unit string) []bucket {
+	buckets := make([]bucket, len(edges)+1)
+	lower := 0.0
+	for i, edge := range edges {
+		buckets[i].Label = fmt.Sprintf("%g-%g %s", lower, edge, unit)
+		lower = edge
+	}
+	buckets[len(edges)].Label = fmt.Sprintf("≥%g %s", lower, unit)
+	for _, value := range values {
+		index, _ := slices.BinarySearchFunc(edges, value, func(edge, value float64) int {
+			if edge <= value {
+				return -1
+			}
+			return 1
+		})
+		buckets[index].Count++
+	}
+	largest := 0
+	for _, b := range buckets {
+		largest = max(largest, b.Count)
+	}
+	if largest > 0 {
+		for i := range buckets {
+			buckets[i].Percent = float64(buckets[i].Count) * 100 / float64(largest)
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/faceair/clash-speedtest/ip"
+	"github.com/faceair/clash-speedtest/speedtester"
+)
+
+func TestGenerate(t *testing.T) {
+	results := []*speedtester.Result{
+		{
+			ProxyName:     "HK <01>",
+			ProxyType:     "Trojan",
+			ProxyConfig:   map[string]any{"server": "hk.example.com"},
+			Latency:       80 * time.Millisecond,
+			DownloadSpeed: 12 * 1024 * 1024,
+		},
+		{
+			ProxyName:     "HK 02",
+			ProxyType:     "Vmess",
+			ProxyConfig:   map[string]any{"server": "hk2.example.com"},
+			Latency:       250 * time.Millisecond,
+			DownloadSpeed: 3 * 1024 * 1024,
+		},
+		{
+			ProxyName:     "Dead",
+			ProxyType:     "Trojan",
+			ProxyConfig:   map[string]any{"server": "dead.example.com"},
+			DownloadError: "download failed: EOF",
+		},
+	}
+	var buf bytes.Buffer
+	err := Generate(&buf, results, Options{
+		Mode:        speedtester.SpeedModeDownload,
+		Version:     "v1.2.3",
+		GeneratedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Locations: map[string]*ip.IPLocation{
+			"hk.example.com":  {CountryCode: "hk"},
+			"hk2.example.com": {CountryCode: "HK"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	html := buf.String()
+
+	for _, expected := range []string{
+		"2/3 nodes available",
+		"version v1.2.3",
+		"HK &lt;01&gt;",
+		"download: download failed: EOF",
+		"<td>HK</td><td class=\"num\">2</td><td class=\"num\">2</td><td class=\"num\">165ms</td><td class=\"num\">7.50MB/s</td><td class=\"num\">12.00MB/s</td>",
+		"<td>Unknown</td>",
+		"<td>Trojan</td><td class=\"num\">2</td><td class=\"num\">1</td>",
+		"Download speed",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected report to contain %q", expected)
+		}
+	}
+	if strings.Contains(html, "<link") || strings.Contains(html, "src=") {
+		t.Errorf("expected a report without external assets")
+	}
+}
+
+func TestGenerateFastModeHidesTransferColumns(t *testing.T) {
+	var buf bytes.Buffer
+	results := []*speedtester.Result{{ProxyName: "a", ProxyType: "Trojan", Latency: 10 * time.Millisecond}}
+	if err := Generate(&buf, results, Options{Mode: speedtester.SpeedModeFast}); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "Download speed") || strings.Contains(buf.String(), ">Upload<") {
+		t.Fatalf("expected fast mode report without transfer sections")
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	buckets := histogram([]float64{50, 100, 150, 2000}, []float64{100, 200}, "ms")
+	expected := []struct {
+		label string
+		count int
+	}{
+		{"0-100 ms", 1},
+		{"100-200 ms", 2},
+		{"≥200 ms", 1},
+	}
+	if len(buckets) != len(expected) {
+		t.Fatalf("expected %d buckets, got %d", len(expected), len(buckets))
+	}
+	for i, b := range buckets {
+		if b.Label != expected[i].label || b.Count != expected[i].count {
+			t.Fatalf("bucket %d: expected %s=%d, got %s=%d", i, expected[i].label, expected[i].count, b.Label, b.Count)
+		}
+	}
+	if buckets[1].Percent != 100 || buckets[0].Percent != 50 {
+		t.Fatalf("expected bar widths relative to the largest bucket, got %+v", buckets)
+	}
+}
@@ -0,0 +1,107 @@
+package speedtester
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/metacubex/mihomo/constant"
+)
+
+// exitIPResponseLimit bounds how much of an IP echo response is read.
+const exitIPResponseLimit = 4 * 1024
+
+// testExitIP asks an IP echo endpoint through the proxy for the address traffic leaves from.
+// The entry server is often a relay or CDN, so this is the address worth geolocating.
+func (st *SpeedTester) testExitIP(ctx context.Context, proxy constant.Proxy) (string, error) {
+	client := st.createClient(proxy, st.config.Timeout)
+	defer client.CloseIdleConnections()
+
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, st.config.ExitIPURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create exit ip request for %s failed: %v", st.config.ExitIPURL, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("exit ip request to %s failed: %v, spent %s", st.config.ExitIPURL, err, time.Since(start))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("exit ip response from %s returned %s, spent %s", st.config.ExitIPURL, resp.Status, time.Since(start))
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, exitIPResponseLimit))
+	if err != nil {
+		return "", fmt.Errorf("read exit ip response from %s failed: %v, spent %s", st.config.ExitIPURL, err, time.Since(start))
+	}
+	exitIP, ok := parseEchoedIP(body)
+	if !ok {
+		return "", fmt.Errorf("no ip address in response from %s", st.config.ExitIPURL)
+	}
+	return exitIP, nil
+}
+
+// parseEchoedIP accepts the common IP echo formats: a bare address, a JSON object with an
+// "ip" field, or key=value lines such as Cloudflare's /cdn-cgi/trace.
+func parseEchoedIP(body []byte) (string, bool) {
+	body = bytes.TrimSpace(body)
+	if addr, err := netip.ParseAddr(string(body)); err == nil {
+		return addr.Unmap().String(), true
+	}
+
+	var payload struct {
+		IP    string `json:"ip"`
+		Query string `json:"query"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		for _, value := range []string{payload.IP, payload.Query} {
+			if addr, err := netip.ParseAddr(value); err == nil {
+				return addr.Unmap().String(), true
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found || strings.TrimSpace(key) != "ip" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(strings.TrimSpace(value)); err == nil {
+			return addr.Unmap().String(), true
+		}
+	}
+	return "", false
+}
+
+// EntryIP returns the configured server of the proxy, which may be a hostname.
+func (r *Result) EntryIP() string {
+	if r.ProxyConfig == nil {
+		return ""
+	}
+	server, _ := r.ProxyConfig["server"].(string)
+	return server
+}
+
+// LocationIP is the address used for geolocation: the exit IP when known, the entry server otherwise.
+func (r *Result) LocationIP() string {
+	if r.ExitIP != "" {
+		return r.ExitIP
+	}
+	return r.EntryIP()
+}
+
+func (r *Result) FormatExitIP() string {
+	if r.ExitIP == "" {
+		return "N/A"
+	}
+	return r.ExitIP
+}
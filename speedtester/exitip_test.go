@@ -0,0 +1,63 @@
+package speedtester
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseEchoedIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+		ok       bool
+	}{
+		{name: "plain", body: "203.0.113.7\n", expected: "203.0.113.7", ok: true},
+		{name: "ipv6", body: "2001:db8::1", expected: "2001:db8::1", ok: true},
+		{name: "mapped ipv4", body: "::ffff:203.0.113.7", expected: "203.0.113.7", ok: true},
+		{name: "json", body: `{"ip":"198.51.100.2"}`, expected: "198.51.100.2", ok: true},
+		{name: "ip-api json", body: `{"status":"success","query":"198.51.100.3"}`, expected: "198.51.100.3", ok: true},
+		{name: "trace", body: "fl=1\nh=example.com\nip=192.0.2.9\nts=1\n", expected: "192.0.2.9", ok: true},
+		{name: "html", body: "<html>blocked</html>", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseEchoedIP([]byte(tt.body))
+			if ok != tt.ok || got != tt.expected {
+				t.Fatalf("expected %q (%v), got %q (%v)", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestExitIPThroughProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		w.Write([]byte(host))
+	}))
+	t.Cleanup(server.Close)
+
+	st := &SpeedTester{config: &Config{Timeout: 5 * time.Second, ExitIPURL: server.URL + "/__ip"}}
+	exitIP, err := st.testExitIP(context.Background(), newDirectProxy())
+	if err != nil {
+		t.Fatalf("exit ip probe failed: %v", err)
+	}
+	if exitIP != "127.0.0.1" {
+		t.Fatalf("expected the direct proxy to exit from 127.0.0.1, got %q", exitIP)
+	}
+}
+
+func TestResultLocationIPFallsBackToEntry(t *testing.T) {
+	result := &Result{ProxyConfig: map[string]any{"server": "relay.example.com"}}
+	if result.LocationIP() != "relay.example.com" {
+		t.Fatalf("expected entry server fallback, got %q", result.LocationIP())
+	}
+	result.ExitIP = "203.0.113.7"
+	if result.LocationIP() != "203.0.113.7" {
+		t.Fatalf("expected exit ip to take precedence, got %q", result.LocationIP())
+	}
+}
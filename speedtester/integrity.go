@@ -0,0 +1,151 @@
+package speedtester
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const (
+	// PayloadSeedHeader is set by the download server when it served seeded content for the request.
+	PayloadSeedHeader = "X-Speedtest-Seed"
+	// HeaderEchoHeader carries the request headers as received by the download server.
+	HeaderEchoHeader = "X-Speedtest-Echo"
+)
+
+// EncodeHeaderEcho serializes request headers so they fit in a single response header value.
+func EncodeHeaderEcho(header http.Header) string {
+	data, _ := json.Marshal(header)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func decodeHeaderEcho(value string) (http.Header, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("decode header echo failed: %w", err)
+	}
+	header := make(http.Header)
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("decode header echo failed: %w", err)
+	}
+	return header, nil
+}
+
+// relayHeaders are hop-by-hop headers and headers added by reverse proxies or CDNs in front of
+// the download server. They change between the proxy and the server without the node tampering.
+var relayHeaders = map[string]bool{
+	"Connection": true, "Keep-Alive": true, "Proxy-Connection": true, "Proxy-Authorization": true,
+	"Te": true, "Trailer": true, "Transfer-Encoding": true, "Upgrade": true,
+	"Forwarded": true, "Via": true, "X-Forwarded-For": true, "X-Forwarded-Host": true,
+	"X-Forwarded-Proto": true, "X-Forwarded-Port": true, "X-Real-Ip": true,
+	"Cdn-Loop": true, "Cf-Connecting-Ip": true, "Cf-Ipcountry": true, "Cf-Ray": true,
+	"Cf-Visitor": true, "True-Client-Ip": true,
+}
+
+// compareHeaderEcho lists the differences between the headers sent and those the server received,
+// ignoring relayHeaders.
+func compareHeaderEcho(sent, received http.Header) []string {
+	var diffs []string
+	for name, values := range received {
+		if relayHeaders[name] {
+			continue
+		}
+		sentValues, ok := sent[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, "injected "+name)
+		case !slices.Equal(sentValues, values):
+			diffs = append(diffs, "modified "+name)
+		}
+	}
+	for name := range sent {
+		if relayHeaders[name] {
+			continue
+		}
+		if _, ok := received[name]; !ok {
+			diffs = append(diffs, "removed "+name)
+		}
+	}
+	slices.Sort(diffs)
+	return diffs
+}
+
+func checkHeaderEcho(sent http.Header, resp *http.Response) string {
+	value := resp.Header.Get(HeaderEchoHeader)
+	if value == "" {
+		return "server did not echo request headers"
+	}
+	received, err := decodeHeaderEcho(value)
+	if err != nil {
+		return err.Error()
+	}
+	if diffs := compareHeaderEcho(sent, received); len(diffs) > 0 {
+		return "request headers altered: " + strings.Join(diffs, ", ")
+	}
+	return ""
+}
+
+// payloadVerifier compares a seeded payload with the content regenerated from its seed,
+// keeping a checksum of both and the offset of the first differing byte.
+type payloadVerifier struct {
+	seed       uint64
+	offset     int64
+	expected   []byte
+	received   hash.Hash32
+	want       hash.Hash32
+	mismatchAt int64
+}
+
+func newPayloadVerifier(seed uint64) *payloadVerifier {
+	return &payloadVerifier{
+		seed:       seed,
+		received:   crc32.NewIEEE(),
+		want:       crc32.NewIEEE(),
+		mismatchAt: -1,
+	}
+}
+
+func (v *payloadVerifier) Write(p []byte) (int, error) {
+	if cap(v.expected) < len(p) {
+		v.expected = make([]byte, len(p))
+	}
+	expected := v.expected[:len(p)]
+	fillSeeded(v.seed, v.offset, expected)
+	if v.mismatchAt < 0 {
+		for i := range p {
+			if p[i] != expected[i] {
+				v.mismatchAt = v.offset + int64(i)
+				break
+			}
+		}
+	}
+	v.received.Write(p)
+	v.want.Write(expected)
+	v.offset += int64(len(p))
+	return len(p), nil
+}
+
+// verify reports a mismatch in content, or in length when the transfer was expected to complete.
+func (v *payloadVerifier) verify(expectedSize int64, complete bool) string {
+	var problems []string
+	if complete && v.offset != expectedSize {
+		problems = append(problems, fmt.Sprintf("length mismatch: got %d bytes, want %d", v.offset, expectedSize))
+	}
+	if v.mismatchAt >= 0 {
+		problems = append(problems, fmt.Sprintf("checksum mismatch: got %08x, want %08x, first difference at byte %d",
+			v.received.Sum32(), v.want.Sum32(), v.mismatchAt))
+	}
+	return strings.Join(problems, "; ")
+}
+
+func (r *Result) FormatIntegrityError() string {
+	if r.IntegrityError == "" {
+		return "N/A"
+	}
+	return r.IntegrityError
+}
@@ -0,0 +1,161 @@
+package speedtester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPayloadVerifier(t *testing.T) {
+	payload, _ := io.ReadAll(NewSeededReader(9, 4096))
+
+	verifier := newPayloadVerifier(9)
+	verifier.Write(payload[:1000])
+	verifier.Write(payload[1000:])
+	if problem := verifier.verify(4096, true); problem != "" {
+		t.Fatalf("expected an intact payload to verify, got %q", problem)
+	}
+
+	tampered := append([]byte(nil), payload...)
+	tampered[2048] ^= 0xff
+	verifier = newPayloadVerifier(9)
+	verifier.Write(tampered)
+	if problem := verifier.verify(4096, true); !strings.Contains(problem, "first difference at byte 2048") {
+		t.Fatalf("expected the tampered byte to be reported, got %q", problem)
+	}
+
+	verifier = newPayloadVerifier(9)
+	verifier.Write(payload[:100])
+	if problem := verifier.verify(4096, true); problem != "length mismatch: got 100 bytes, want 4096" {
+		t.Fatalf("expected a truncated payload to be reported, got %q", problem)
+	}
+	if problem := verifier.verify(4096, false); problem != "" {
+		t.Fatalf("expected a window-ended transfer to skip the length check, got %q", problem)
+	}
+}
+
+func TestCompareHeaderEcho(t *testing.T) {
+	sent := http.Header{
+		"User-Agent":      {"clash-speedtest"},
+		"Accept-Encoding": {"identity"},
+		"X-Removed":       {"1"},
+	}
+	echoed, err := decodeHeaderEcho(EncodeHeaderEcho(http.Header{
+		"User-Agent":      {"rewritten"},
+		"Accept-Encoding": {"identity"},
+		"X-Injected":      {"ad"},
+		"X-Forwarded-For": {"10.0.0.1"},
+		"Via":             {"1.1 nginx"},
+	}))
+	if err != nil {
+		t.Fatalf("decode header echo failed: %v", err)
+	}
+	diffs := compareHeaderEcho(sent, echoed)
+	expected := []string{"injected X-Injected", "modified User-Agent", "removed X-Removed"}
+	if strings.Join(diffs, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, diffs)
+	}
+}
+
+// newSeededServer mimics the download-server /__down handler; tamper can alter the response.
+func newSeededServer(t *testing.T, tamper func(r *http.Request, payload []byte) []byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("bytes"))
+		seedValue := r.URL.Query().Get("seed")
+		seed, _ := strconv.ParseUint(seedValue, 10, 64)
+		payload, _ := io.ReadAll(NewSeededReader(seed, size))
+		if tamper != nil {
+			payload = tamper(r, payload)
+		}
+		w.Header().Set(PayloadSeedHeader, seedValue)
+		w.Header().Set(HeaderEchoHeader, EncodeHeaderEcho(r.Header))
+		w.Write(payload)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadVerifiesPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(r *http.Request, payload []byte) []byte
+		expected string
+	}{
+		{name: "intact"},
+		{
+			name: "injected content",
+			tamper: func(_ *http.Request, payload []byte) []byte {
+				copy(payload[100:], "<script>")
+				return payload
+			},
+			expected: "checksum mismatch",
+		},
+		{
+			name: "truncated",
+			tamper: func(_ *http.Request, payload []byte) []byte {
+				return payload[:len(payload)/2]
+			},
+			expected: "length mismatch",
+		},
+		{
+			name: "injected header",
+			tamper: func(r *http.Request, payload []byte) []byte {
+				r.Header.Set("X-Injected", "middlebox")
+				return payload
+			},
+			expected: "request headers altered: injected X-Injected",
+		},
+		{
+			name: "reverse proxy headers",
+			tamper: func(r *http.Request, payload []byte) []byte {
+				r.Header.Set("Via", "1.1 nginx")
+				r.Header.Set("X-Forwarded-For", "10.0.0.1")
+				return payload
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSeededServer(t, tt.tamper)
+			st := &SpeedTester{
+				config:        &Config{VerifyPayload: true},
+				serverMode:    serverModeDownloadServer,
+				serverBaseURL: server.URL,
+			}
+			result := st.testDownload(context.Background(), newDirectProxy(), 64*1024, 5*time.Second, 0, nil)
+			if result.error != "" {
+				t.Fatalf("unexpected download error: %s", result.error)
+			}
+			if tt.expected == "" && result.integrityError != "" {
+				t.Fatalf("expected an intact payload to pass, got %q", result.integrityError)
+			}
+			if !strings.Contains(result.integrityError, tt.expected) {
+				t.Fatalf("expected integrity error to contain %q, got %q", tt.expected, result.integrityError)
+			}
+		})
+	}
+}
+
+func TestDownloadVerifyRequiresSeededServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("bytes"))
+		io.Copy(w, NewZeroReader(size))
+	}))
+	t.Cleanup(server.Close)
+
+	st := &SpeedTester{
+		config:        &Config{VerifyPayload: true},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+	result := st.testDownload(context.Background(), newDirectProxy(), 1024, 5*time.Second, 0, nil)
+	if !strings.Contains(result.integrityError, "server did not serve the seeded payload") {
+		t.Fatalf("expected a missing seed to be reported, got %q", result.integrityError)
+	}
+}
@@ -0,0 +1,58 @@
+package speedtester
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/metacubex/mihomo/constant"
+)
+
+const minLoadedProbeInterval = 100 * time.Millisecond
+
+// probeLoadedLatency sends HEAD requests through the proxy on its own connection until ctx
+// is done, measuring how responsive the node stays while transfers saturate it.
+func (st *SpeedTester) probeLoadedLatency(ctx context.Context, proxy constant.Proxy) []time.Duration {
+	client := st.createClient(proxy, st.config.Timeout)
+	defer client.CloseIdleConnections()
+
+	interval := max(st.config.PingInterval, minLoadedProbeInterval)
+	var latencies []time.Duration
+	for {
+		if err := sleepContext(ctx, interval); err != nil {
+			return latencies
+		}
+		start := time.Now()
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, st.latencyURL(), nil)
+		if err != nil {
+			return latencies
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		latencies = append(latencies, time.Since(start))
+	}
+}
+
+// latencyURL is the target of latency probes: the direct download URL itself, or an
+// empty download from the download server.
+func (st *SpeedTester) latencyURL() string {
+	if st.serverMode == serverModeDirectDownload {
+		return st.downloadURL
+	}
+	return fmt.Sprintf("%s/__down?bytes=0", st.serverBaseURL)
+}
+
+func averageLatency(latencies []time.Duration) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	return total / time.Duration(len(latencies))
+}
@@ -0,0 +1,61 @@
+package speedtester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeLoadedLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/__down" || r.URL.Query().Get("bytes") != "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	st := &SpeedTester{
+		config:        &Config{Timeout: time.Second},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 550*time.Millisecond)
+	defer cancel()
+
+	latencies := st.probeLoadedLatency(ctx, newDirectProxy())
+	if len(latencies) < 2 {
+		t.Fatalf("expected several probes within the window, got %d", len(latencies))
+	}
+	if average := averageLatency(latencies); average < 10*time.Millisecond {
+		t.Fatalf("expected probe latency to include the server delay, got %s", average)
+	}
+}
+
+func TestLatencyURL(t *testing.T) {
+	st := &SpeedTester{serverMode: serverModeDownloadServer, serverBaseURL: "https://example.com"}
+	if got := st.latencyURL(); got != "https://example.com/__down?bytes=0" {
+		t.Fatalf("expected download server latency url, got %q", got)
+	}
+
+	st = &SpeedTester{serverMode: serverModeDirectDownload, downloadURL: "https://example.com/file.bin"}
+	if got := st.latencyURL(); got != "https://example.com/file.bin" {
+		t.Fatalf("expected direct download url, got %q", got)
+	}
+}
+
+func TestResultFormatBufferbloat(t *testing.T) {
+	result := &Result{Latency: 100 * time.Millisecond}
+	if result.FormatBufferbloat() != "N/A" {
+		t.Fatalf("expected N/A without loaded latency, got %q", result.FormatBufferbloat())
+	}
+	result.LoadedLatency = 350 * time.Millisecond
+	result.BufferbloatDelta = 250 * time.Millisecond
+	if result.FormatBufferbloat() != "+250ms" {
+		t.Fatalf("expected +250ms, got %q", result.FormatBufferbloat())
+	}
+}
@@ -11,6 +11,8 @@ const (
 	SpeedModeFast     SpeedMode = "fast"
 	SpeedModeDownload SpeedMode = "download"
 	SpeedModeFull     SpeedMode = "full"
+	// SpeedModeSustained runs the download test plus a long single-stream transfer for throttle detection.
+	SpeedModeSustained SpeedMode = "sustained"
 )
 
 func ParseSpeedMode(value string) (SpeedMode, error) {
@@ -22,6 +24,8 @@ func ParseSpeedMode(value string) (SpeedMode, error) {
 		return SpeedModeDownload, nil
 	case SpeedModeFull:
 		return SpeedModeFull, nil
+	case SpeedModeSustained:
+		return SpeedModeSustained, nil
 	default:
 		return "", fmt.Errorf("unsupported speed mode %q", value)
 	}
@@ -34,3 +38,7 @@ func (m SpeedMode) IsFast() bool {
 func (m SpeedMode) UploadEnabled() bool {
 	return m == SpeedModeFull
 }
+
+func (m SpeedMode) SustainedEnabled() bool {
+	return m == SpeedModeSustained
+}
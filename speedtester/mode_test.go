@@ -24,6 +24,11 @@ func TestParseSpeedMode(t *testing.T) {
 			input:    "full",
 			expected: SpeedModeFull,
 		},
+		{
+			name:     "sustained",
+			input:    " Sustained ",
+			expected: SpeedModeSustained,
+		},
 		{
 			name:      "invalid",
 			input:     "slow",
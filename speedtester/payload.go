@@ -0,0 +1,42 @@
+package speedtester
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PayloadType selects the content of transfer payloads. Zeros compress to almost nothing, so
+// proxies with a compressing transport report inflated speeds unless random content is used.
+type PayloadType string
+
+const (
+	PayloadZero   PayloadType = "zero"
+	PayloadRandom PayloadType = "random"
+)
+
+func ParsePayloadType(value string) (PayloadType, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch PayloadType(normalized) {
+	case PayloadZero:
+		return PayloadZero, nil
+	case PayloadRandom:
+		return PayloadRandom, nil
+	default:
+		return "", fmt.Errorf("unsupported payload type %q", value)
+	}
+}
+
+// PayloadReader streams a payload and reports how much of it has been read.
+type PayloadReader interface {
+	io.Reader
+	WrittenBytes() int64
+}
+
+// NewPayloadReader returns size bytes of the given payload type; seed only affects random payloads.
+func NewPayloadReader(payloadType PayloadType, seed uint64, size int) PayloadReader {
+	if payloadType == PayloadRandom {
+		return NewSeededReader(seed, size)
+	}
+	return NewZeroReader(size)
+}
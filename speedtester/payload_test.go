@@ -0,0 +1,71 @@
+package speedtester
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParsePayloadType(t *testing.T) {
+	payloadType, err := ParsePayloadType(" Random ")
+	if err != nil || payloadType != PayloadRandom {
+		t.Fatalf("expected random payload, got %q (%v)", payloadType, err)
+	}
+	if _, err := ParsePayloadType("ones"); err == nil {
+		t.Fatalf("expected unsupported payload type to fail")
+	}
+}
+
+func TestRandomPayloadIsIncompressible(t *testing.T) {
+	compressedSize := func(reader io.Reader) int {
+		var buffer bytes.Buffer
+		writer, _ := flate.NewWriter(&buffer, flate.BestSpeed)
+		io.Copy(writer, reader)
+		writer.Close()
+		return buffer.Len()
+	}
+
+	const size = 1 << 20
+	if zero := compressedSize(NewPayloadReader(PayloadZero, 0, size)); zero > size/100 {
+		t.Fatalf("expected zero payload to compress well, got %d bytes", zero)
+	}
+	if random := compressedSize(NewPayloadReader(PayloadRandom, 1, size)); random < size {
+		t.Fatalf("expected random payload not to compress, got %d bytes", random)
+	}
+}
+
+func TestPayloadTypeSelectsTransferContent(t *testing.T) {
+	var downloadQuery string
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			uploaded, _ = io.ReadAll(r.Body)
+			return
+		}
+		downloadQuery = r.URL.RawQuery
+	}))
+	t.Cleanup(server.Close)
+
+	st := &SpeedTester{
+		config:        &Config{PayloadType: PayloadRandom},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+	st.testDownload(context.Background(), newDirectProxy(), 1024, 5*time.Second, 0, nil)
+	if downloadQuery != "bytes=1024&payload=random" {
+		t.Fatalf("expected download to request a random payload, got %q", downloadQuery)
+	}
+
+	result := st.testUpload(context.Background(), newDirectProxy(), 4096, 5*time.Second, 0, nil)
+	if result.error != "" || result.bytes != 4096 {
+		t.Fatalf("expected 4096 uploaded bytes, got %d (%s)", result.bytes, result.error)
+	}
+	if bytes.Equal(uploaded, make([]byte, 4096)) {
+		t.Fatalf("expected random upload payload, got zeros")
+	}
+}
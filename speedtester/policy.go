@@ -0,0 +1,47 @@
+package speedtester
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransferPolicy decides whether a transfer with failed streams still reports a speed.
+type TransferPolicy string
+
+const (
+	// TransferPolicyStrict zeroes the speed if any stream failed.
+	TransferPolicyStrict TransferPolicy = "strict"
+	// TransferPolicyBestEffort reports the speed of the successful streams.
+	TransferPolicyBestEffort TransferPolicy = "best-effort"
+	// TransferPolicyMinSuccess reports a speed when enough streams succeeded.
+	TransferPolicyMinSuccess TransferPolicy = "min-success"
+)
+
+func ParseTransferPolicy(value string) (TransferPolicy, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch TransferPolicy(normalized) {
+	case TransferPolicyStrict:
+		return TransferPolicyStrict, nil
+	case TransferPolicyBestEffort:
+		return TransferPolicyBestEffort, nil
+	case TransferPolicyMinSuccess:
+		return TransferPolicyMinSuccess, nil
+	default:
+		return "", fmt.Errorf("unsupported transfer policy %q", value)
+	}
+}
+
+// accepts reports whether the outcome of a transfer's streams still yields a speed.
+func (p TransferPolicy) accepts(successCount, failureCount int, minSuccessRatio float64) bool {
+	if successCount == 0 {
+		return false
+	}
+	switch p {
+	case TransferPolicyBestEffort:
+		return true
+	case TransferPolicyMinSuccess:
+		return float64(successCount)/float64(successCount+failureCount) >= minSuccessRatio
+	default:
+		return failureCount == 0
+	}
+}
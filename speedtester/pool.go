@@ -0,0 +1,94 @@
+package speedtester
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type proxyJob struct {
+	name      string
+	proxy     *CProxy
+	serverKey string
+}
+
+// serverLimiter caps how many proxies sharing the same entry server are tested at once.
+// A limit of zero or less disables the cap.
+type serverLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newServerLimiter(limit int) *serverLimiter {
+	return &serverLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+func (l *serverLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	if l == nil || l.limit <= 0 || key == "" {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	slot, ok := l.slots[key]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[key] = slot
+	}
+	l.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() {
+			<-slot
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func buildProxyServerKey(proxy *CProxy) string {
+	if proxy == nil || proxy.Config == nil {
+		return ""
+	}
+	serverValue, ok := proxy.Config["server"]
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(fmt.Sprintf("%v", serverValue)))
+}
+
+// buildProxyJobs orders proxies so that nodes sharing an entry server are spread
+// across the queue, which keeps workers from piling up on a single server.
+func buildProxyJobs(proxies map[string]*CProxy) []proxyJob {
+	groups := make(map[string][]proxyJob)
+	keys := make([]string, 0)
+	for name, proxy := range proxies {
+		key := buildProxyServerKey(proxy)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], proxyJob{name: name, proxy: proxy, serverKey: key})
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		group := groups[key]
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].name < group[j].name
+		})
+	}
+
+	jobs := make([]proxyJob, 0, len(proxies))
+	for round := 0; len(jobs) < len(proxies); round++ {
+		for _, key := range keys {
+			if group := groups[key]; round < len(group) {
+				jobs = append(jobs, group[round])
+			}
+		}
+	}
+	return jobs
+}
@@ -0,0 +1,103 @@
+package speedtester
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBuildProxyJobsInterleavesServers(t *testing.T) {
+	proxies := map[string]*CProxy{
+		"a-1": {Config: map[string]any{"server": "relay-a.example.com", "port": 1001}},
+		"a-2": {Config: map[string]any{"server": "relay-a.example.com", "port": 1002}},
+		"a-3": {Config: map[string]any{"server": "RELAY-A.example.com", "port": 1003}},
+		"b-1": {Config: map[string]any{"server": "relay-b.example.com", "port": 2001}},
+		"c-1": {Config: map[string]any{"server": "relay-c.example.com", "port": 3001}},
+	}
+
+	jobs := buildProxyJobs(proxies)
+	if len(jobs) != len(proxies) {
+		t.Fatalf("expected %d jobs, got %d", len(proxies), len(jobs))
+	}
+	expected := []string{"a-1", "b-1", "c-1", "a-2", "a-3"}
+	for i, name := range expected {
+		if jobs[i].name != name {
+			t.Fatalf("expected job %d to be %s, got %s", i, name, jobs[i].name)
+		}
+	}
+	if jobs[4].serverKey != "relay-a.example.com" {
+		t.Fatalf("expected server key to be normalized, got %q", jobs[4].serverKey)
+	}
+}
+
+func TestServerLimiterCapsSameServer(t *testing.T) {
+	limiter := newServerLimiter(2)
+
+	var running atomic.Int32
+	var peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.acquire(context.Background(), "relay.example.com")
+			if err != nil {
+				t.Errorf("acquire failed: %v", err)
+				return
+			}
+			defer release()
+			current := running.Add(1)
+			for {
+				previous := peak.Load()
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Fatalf("expected at most 2 concurrent tests for one server, got %d", peak.Load())
+	}
+}
+
+func TestServerLimiterUnlimited(t *testing.T) {
+	limiter := newServerLimiter(0)
+	releases := make([]func(), 0, 4)
+	for range 4 {
+		release, err := limiter.acquire(context.Background(), "relay.example.com")
+		if err != nil {
+			t.Fatalf("acquire failed: %v", err)
+		}
+		releases = append(releases, release)
+	}
+	for _, release := range releases {
+		release()
+	}
+
+	limiter = newServerLimiter(1)
+	first, _ := limiter.acquire(context.Background(), "")
+	second, _ := limiter.acquire(context.Background(), "")
+	first()
+	second()
+}
+
+func TestServerLimiterAcquireCancelled(t *testing.T) {
+	limiter := newServerLimiter(1)
+	release, err := limiter.acquire(context.Background(), "relay.example.com")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := limiter.acquire(ctx, "relay.example.com"); err == nil {
+		t.Fatalf("expected acquire to fail once the context is cancelled")
+	}
+}
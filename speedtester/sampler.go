@@ -0,0 +1,190 @@
+package speedtester
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// steadyStateRampRatio marks the end of slow-start: the first sample reaching this share of the peak.
+	steadyStateRampRatio = 0.5
+	// stallSpeedRatio marks a stalled interval: throughput below this share of the peak.
+	stallSpeedRatio = 0.1
+)
+
+// ThroughputStats describes how throughput evolved during a transfer.
+type ThroughputStats struct {
+	PeakSpeed     float64       `json:"peak_speed"`
+	SteadySpeed   float64       `json:"steady_speed"`
+	StallCount    int           `json:"stall_count"`
+	StallDuration time.Duration `json:"stall_duration"`
+	// Samples holds the aggregate speed of all streams (bytes/s) for each sample interval.
+	Samples        []float64     `json:"samples"`
+	SampleInterval time.Duration `json:"sample_interval"`
+}
+
+// throughputSampler counts bytes moved by all streams of a transfer and snapshots
+// the count every interval.
+type throughputSampler struct {
+	interval time.Duration
+	bytes    atomic.Int64
+	mu       sync.Mutex
+	samples  []float64
+	lastTick time.Time
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func newThroughputSampler(interval time.Duration) *throughputSampler {
+	return &throughputSampler{
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *throughputSampler) start() {
+	if s == nil || s.interval <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.lastTick = time.Now()
+	s.mu.Unlock()
+	go s.run()
+}
+
+func (s *throughputSampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.snapshot(now, false)
+		}
+	}
+}
+
+func (s *throughputSampler) snapshot(now time.Time, final bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := now.Sub(s.lastTick)
+	// A short trailing interval would report a noisy speed, so it is dropped.
+	if elapsed <= 0 || (final && elapsed < s.interval/2) {
+		return
+	}
+	s.lastTick = now
+	s.samples = append(s.samples, float64(s.bytes.Swap(0))/elapsed.Seconds())
+}
+
+func (s *throughputSampler) add(n int) {
+	if s == nil || n <= 0 {
+		return
+	}
+	s.bytes.Add(int64(n))
+}
+
+// finish stops sampling and returns the recorded series. It must be called once, after start.
+func (s *throughputSampler) finish() []float64 {
+	if s == nil || s.interval <= 0 {
+		return nil
+	}
+	close(s.stop)
+	<-s.done
+	s.snapshot(time.Now(), true)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	samples := make([]float64, len(s.samples))
+	copy(samples, s.samples)
+	return samples
+}
+
+type countingReader struct {
+	reader  io.Reader
+	sampler *throughputSampler
+}
+
+func newCountingReader(reader io.Reader, sampler *throughputSampler) io.Reader {
+	if sampler == nil {
+		return reader
+	}
+	return &countingReader{reader: reader, sampler: sampler}
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.sampler.add(n)
+	return n, err
+}
+
+func summarizeThroughput(samples []float64, interval time.Duration) ThroughputStats {
+	stats := ThroughputStats{
+		Samples:        samples,
+		SampleInterval: interval,
+	}
+	if len(samples) == 0 {
+		return stats
+	}
+	for _, sample := range samples {
+		stats.PeakSpeed = max(stats.PeakSpeed, sample)
+	}
+	if stats.PeakSpeed <= 0 {
+		return stats
+	}
+
+	rampEnd := 0
+	for i, sample := range samples {
+		if sample >= stats.PeakSpeed*steadyStateRampRatio {
+			rampEnd = i
+			break
+		}
+	}
+	var steadyTotal float64
+	for _, sample := range samples[rampEnd:] {
+		steadyTotal += sample
+	}
+	stats.SteadySpeed = steadyTotal / float64(len(samples)-rampEnd)
+
+	// Intervals before the first byte arrives are connection setup, not stalls.
+	firstByte := 0
+	for firstByte < len(samples) && samples[firstByte] == 0 {
+		firstByte++
+	}
+	stalled := false
+	for _, sample := range samples[firstByte:] {
+		if sample < stats.PeakSpeed*stallSpeedRatio {
+			if !stalled {
+				stats.StallCount++
+			}
+			stalled = true
+			stats.StallDuration += interval
+			continue
+		}
+		stalled = false
+	}
+	return stats
+}
+
+func (s ThroughputStats) FormatPeakSpeed() string {
+	return formatSpeed(s.PeakSpeed)
+}
+
+func (s ThroughputStats) FormatSteadySpeed() string {
+	return formatSpeed(s.SteadySpeed)
+}
+
+func (s ThroughputStats) FormatStalls() string {
+	if len(s.Samples) == 0 {
+		return "N/A"
+	}
+	if s.StallCount == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%s)", s.StallCount, s.StallDuration)
+}
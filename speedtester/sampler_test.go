@@ -0,0 +1,70 @@
+package speedtester
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSummarizeThroughput(t *testing.T) {
+	// slow-start, steady, a two-interval stall, recovery
+	samples := []float64{0, 10, 40, 100, 90, 0, 5, 80, 100}
+	stats := summarizeThroughput(samples, 250*time.Millisecond)
+
+	if stats.PeakSpeed != 100 {
+		t.Fatalf("expected peak 100, got %.2f", stats.PeakSpeed)
+	}
+	expectedSteady := (100 + 90 + 0 + 5 + 80 + 100) / 6.0
+	if stats.SteadySpeed != expectedSteady {
+		t.Fatalf("expected steady speed %.2f, got %.2f", expectedSteady, stats.SteadySpeed)
+	}
+	if stats.StallCount != 1 {
+		t.Fatalf("expected one stall, got %d", stats.StallCount)
+	}
+	if stats.StallDuration != 500*time.Millisecond {
+		t.Fatalf("expected stall duration 500ms, got %s", stats.StallDuration)
+	}
+	if stats.FormatStalls() != "1 (500ms)" {
+		t.Fatalf("expected stalls to format as 1 (500ms), got %q", stats.FormatStalls())
+	}
+}
+
+func TestSummarizeThroughputEmpty(t *testing.T) {
+	stats := summarizeThroughput(nil, 250*time.Millisecond)
+	if stats.PeakSpeed != 0 || stats.SteadySpeed != 0 || stats.StallCount != 0 {
+		t.Fatalf("expected zero stats for no samples, got %+v", stats)
+	}
+	if stats.FormatStalls() != "N/A" {
+		t.Fatalf("expected stalls to format as N/A, got %q", stats.FormatStalls())
+	}
+}
+
+func TestThroughputSamplerRecordsIntervals(t *testing.T) {
+	sampler := newThroughputSampler(20 * time.Millisecond)
+	sampler.start()
+	reader := newCountingReader(bytes.NewReader(make([]byte, 4096)), sampler)
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	time.Sleep(70 * time.Millisecond)
+	samples := sampler.finish()
+
+	if len(samples) < 2 {
+		t.Fatalf("expected several samples, got %d", len(samples))
+	}
+	var total float64
+	for _, sample := range samples {
+		total += sample
+	}
+	if total <= 0 {
+		t.Fatalf("expected the counted bytes to show up in the samples, got %v", samples)
+	}
+}
+
+func TestCountingReaderWithoutSampler(t *testing.T) {
+	source := bytes.NewReader(nil)
+	if newCountingReader(source, nil) != io.Reader(source) {
+		t.Fatalf("expected reader to pass through without a sampler")
+	}
+}
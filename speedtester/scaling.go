@@ -0,0 +1,114 @@
+package speedtester
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/metacubex/mihomo/constant"
+)
+
+var defaultScalingStreams = []int{1, 2, 4, 8}
+
+// ScalingStep is the download speed measured with a given number of parallel streams.
+type ScalingStep struct {
+	Streams int     `json:"streams"`
+	Speed   float64 `json:"speed"`
+	Error   string  `json:"error"`
+}
+
+// ParseStreamCounts parses a comma separated list of stream counts such as "1,2,4,8".
+func ParseStreamCounts(value string) ([]int, error) {
+	var counts []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		count, err := strconv.Atoi(field)
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("invalid stream count %q", field)
+		}
+		counts = append(counts, count)
+	}
+	return normalizeStreamCounts(counts), nil
+}
+
+func normalizeStreamCounts(counts []int) []int {
+	normalized := make([]int, 0, len(counts))
+	for _, count := range counts {
+		if count > 0 {
+			normalized = append(normalized, count)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// testConcurrencyScaling repeats the download with each configured stream count. Nodes that cap
+// each connection scale with more streams; nodes that cap total bandwidth stay flat.
+func (st *SpeedTester) testConcurrencyScaling(ctx context.Context, proxy constant.Proxy) []ScalingStep {
+	steps := make([]ScalingStep, 0, len(st.config.ScalingStreams))
+	for _, streams := range st.config.ScalingStreams {
+		if ctx.Err() != nil {
+			break
+		}
+		timeout := st.config.Timeout
+		chunkSize := st.config.DownloadSize / streams
+		if st.config.TestDuration > 0 {
+			timeout += st.config.TestDuration
+			chunkSize = durationModeTransferSize
+		}
+		if chunkSize <= 0 {
+			continue
+		}
+		summary := st.runDownloadStreams(ctx, proxy, streams, chunkSize, timeout, nil)
+		_, _, speed, errorMessage := applyTransferSummary(summary, st.config.TransferPolicy, st.config.MinSuccessRatio)
+		steps = append(steps, ScalingStep{Streams: streams, Speed: speed, Error: errorMessage})
+	}
+	return steps
+}
+
+func applyScaling(result *Result, steps []ScalingStep) {
+	result.ScalingSteps = steps
+	for _, step := range steps {
+		if step.Streams == 1 {
+			result.SingleStreamSpeed = step.Speed
+			continue
+		}
+		if step.Speed > result.BestStreamSpeed {
+			result.BestStreamSpeed = step.Speed
+			result.BestStreamCount = step.Streams
+		}
+	}
+}
+
+// ScalingGain is the best multi-stream speed relative to the single-stream speed.
+// A gain well above 1 means the node limits each connection rather than total bandwidth.
+func (r *Result) ScalingGain() float64 {
+	if r.SingleStreamSpeed <= 0 || r.BestStreamSpeed <= 0 {
+		return 0
+	}
+	return r.BestStreamSpeed / r.SingleStreamSpeed
+}
+
+func (r *Result) FormatSingleStreamSpeed() string {
+	return formatSpeed(r.SingleStreamSpeed)
+}
+
+func (r *Result) FormatBestStreamSpeed() string {
+	if r.BestStreamCount == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%s @ %d streams", formatSpeed(r.BestStreamSpeed), r.BestStreamCount)
+}
+
+func (r *Result) FormatScalingGain() string {
+	gain := r.ScalingGain()
+	if gain == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("x%.2f", gain)
+}
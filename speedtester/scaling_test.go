@@ -0,0 +1,80 @@
+package speedtester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseStreamCounts(t *testing.T) {
+	counts, err := ParseStreamCounts(" 8, 1,4,2,4 ")
+	if err != nil {
+		t.Fatalf("parse stream counts failed: %v", err)
+	}
+	if !slices.Equal(counts, []int{1, 2, 4, 8}) {
+		t.Fatalf("expected sorted unique counts, got %v", counts)
+	}
+	for _, value := range []string{"1,x", "0", "-2"} {
+		if _, err := ParseStreamCounts(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestApplyScalingPicksBestMultiStream(t *testing.T) {
+	result := &Result{}
+	applyScaling(result, []ScalingStep{
+		{Streams: 1, Speed: 10},
+		{Streams: 2, Speed: 19},
+		{Streams: 4, Speed: 35},
+		{Streams: 8, Speed: 30},
+	})
+	if result.SingleStreamSpeed != 10 {
+		t.Fatalf("expected single-stream speed 10, got %v", result.SingleStreamSpeed)
+	}
+	if result.BestStreamSpeed != 35 || result.BestStreamCount != 4 {
+		t.Fatalf("expected best speed 35 at 4 streams, got %v at %d", result.BestStreamSpeed, result.BestStreamCount)
+	}
+	if result.FormatScalingGain() != "x3.50" {
+		t.Fatalf("expected gain x3.50, got %q", result.FormatScalingGain())
+	}
+	if (&Result{}).FormatBestStreamSpeed() != "N/A" {
+		t.Fatalf("expected missing scaling result to format as N/A")
+	}
+}
+
+func TestConcurrencyScalingMeasuresEachStreamCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("bytes"))
+		w.Write(make([]byte, size))
+	}))
+	t.Cleanup(server.Close)
+
+	st := &SpeedTester{
+		config: &Config{
+			DownloadSize:   64 * 1024,
+			Timeout:        5 * time.Second,
+			TransferPolicy: TransferPolicyStrict,
+			ScalingStreams: []int{1, 2, 4},
+		},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	steps := st.testConcurrencyScaling(context.Background(), newDirectProxy())
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 scaling steps, got %d", len(steps))
+	}
+	for i, streams := range []int{1, 2, 4} {
+		if steps[i].Streams != streams {
+			t.Fatalf("expected step %d to use %d streams, got %d", i, streams, steps[i].Streams)
+		}
+		if steps[i].Error != "" || steps[i].Speed <= 0 {
+			t.Fatalf("expected step with %d streams to report a speed, got %+v", streams, steps[i])
+		}
+	}
+}
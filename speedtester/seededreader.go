@@ -0,0 +1,68 @@
+package speedtester
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// SeededReader produces deterministic pseudo-random content: the same seed always yields the
+// same bytes, so the receiver can verify a payload without the sender shipping a checksum.
+type SeededReader struct {
+	seed         uint64
+	remainBytes  int64
+	writtenBytes int64
+}
+
+func NewSeededReader(seed uint64, size int) *SeededReader {
+	return &SeededReader{
+		seed:        seed,
+		remainBytes: int64(size),
+	}
+}
+
+func (r *SeededReader) Read(p []byte) (n int, err error) {
+	if r.remainBytes <= 0 {
+		return 0, io.EOF
+	}
+	toRead := int(min(int64(len(p)), r.remainBytes))
+	fillSeeded(r.seed, r.writtenBytes, p[:toRead])
+	r.remainBytes -= int64(toRead)
+	r.writtenBytes += int64(toRead)
+	return toRead, nil
+}
+
+func (r *SeededReader) WrittenBytes() int64 {
+	return r.writtenBytes
+}
+
+func (r *SeededReader) RemainBytes() int64 {
+	return r.remainBytes
+}
+
+// fillSeeded writes the content found at offset of the stream for seed into p. Each 8-byte word
+// depends only on the seed and its position, so any range can be generated independently.
+func fillSeeded(seed uint64, offset int64, p []byte) {
+	var word [8]byte
+	for len(p) > 0 {
+		index := uint64(offset) / 8
+		skip := int(uint64(offset) % 8)
+		if skip == 0 && len(p) >= 8 {
+			binary.LittleEndian.PutUint64(p, seededWord(seed, index))
+			p = p[8:]
+			offset += 8
+			continue
+		}
+		binary.LittleEndian.PutUint64(word[:], seededWord(seed, index))
+		n := copy(p, word[skip:])
+		p = p[n:]
+		offset += int64(n)
+	}
+}
+
+// seededWord is the SplitMix64 output for the given position of the stream.
+func seededWord(seed, index uint64) uint64 {
+	z := seed + (index+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
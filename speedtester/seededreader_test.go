@@ -0,0 +1,52 @@
+package speedtester
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSeededReaderIsDeterministic(t *testing.T) {
+	first, err := io.ReadAll(NewSeededReader(42, 1000))
+	if err != nil {
+		t.Fatalf("read seeded payload failed: %v", err)
+	}
+	if len(first) != 1000 {
+		t.Fatalf("expected 1000 bytes, got %d", len(first))
+	}
+
+	// Reading in odd-sized chunks must produce the same stream.
+	reader := NewSeededReader(42, 1000)
+	var second bytes.Buffer
+	buffer := make([]byte, 7)
+	for {
+		n, err := reader.Read(buffer)
+		second.Write(buffer[:n])
+		if err == io.EOF {
+			break
+		}
+	}
+	if !bytes.Equal(first, second.Bytes()) {
+		t.Fatalf("expected chunked reads to match a single read")
+	}
+	if reader.WrittenBytes() != 1000 || reader.RemainBytes() != 0 {
+		t.Fatalf("expected 1000 written and 0 remaining, got %d and %d", reader.WrittenBytes(), reader.RemainBytes())
+	}
+
+	other, _ := io.ReadAll(NewSeededReader(43, 1000))
+	if bytes.Equal(first, other) {
+		t.Fatalf("expected different seeds to produce different content")
+	}
+	if bytes.Equal(first[:8], make([]byte, 8)) {
+		t.Fatalf("expected seeded content not to be zeros")
+	}
+}
+
+func TestFillSeededAtOffset(t *testing.T) {
+	full, _ := io.ReadAll(NewSeededReader(7, 64))
+	part := make([]byte, 13)
+	fillSeeded(7, 29, part)
+	if !bytes.Equal(part, full[29:42]) {
+		t.Fatalf("expected content at offset 29 to match the stream")
+	}
+}
@@ -2,20 +2,24 @@ package speedtester
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
+	"math/rand/v2"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/faceair/clash-speedtest/convert"
 	"github.com/metacubex/mihomo/adapter"
 	"github.com/metacubex/mihomo/adapter/provider"
 	"github.com/metacubex/mihomo/constant"
@@ -23,23 +27,41 @@ import (
 )
 
 type Config struct {
-	ConfigPaths      string
-	FilterRegex      string
-	BlockRegex       string
-	ServerURL        string
-	DownloadSize     int
-	UploadSize       int
-	Timeout          time.Duration
-	Concurrent       int
-	MaxLatency       time.Duration
-	MaxPacketLoss    float64
-	MinDownloadSpeed float64
-	MinUploadSpeed   float64
-	Mode             SpeedMode
-	OutputPath       string
-	UserAgent        string // optional; empty means use default (mihomo kernel UA)
+	ConfigPaths        string
+	FilterRegex        string
+	BlockRegex         string
+	ServerURL          string
+	DownloadSize       int
+	UploadSize         int
+	TestDuration       time.Duration // when positive, transfer for this long per node instead of a fixed size
+	SampleInterval     time.Duration // throughput sampling interval for peak/steady speed and stall detection
+	Timeout            time.Duration
+	Concurrent         int
+	TransferPolicy     TransferPolicy // how failed streams affect the reported speed
+	MinSuccessRatio    float64        // share of streams that must succeed under TransferPolicyMinSuccess
+	PingCount          int            // number of latency probes per proxy
+	PingInterval       time.Duration  // wait before each latency probe
+	ProxyConcurrency   int            // number of proxies tested in parallel
+	ServerConcurrency  int            // max parallel tests per entry server; 0 means unlimited
+	MaxLatency         time.Duration
+	MaxPacketLoss      float64
+	MinDownloadSpeed   float64
+	MinUploadSpeed     float64
+	Mode               SpeedMode
+	OutputPath         string
+	UserAgent          string        // optional; empty means use default (mihomo kernel UA)
+	UDPServer          string        // optional UDP echo server (host:port); empty disables the UDP test
+	ConcurrencyScaling bool          // measure download speed at each of ScalingStreams after the main test
+	ScalingStreams     []int         // stream counts for the concurrency scaling test
+	SustainedDuration  time.Duration // length of the single-stream transfer in SpeedModeSustained
+	VerifyPayload      bool          // request seeded content from the download server and verify it
+	PayloadType        PayloadType   // content of upload payloads and requested download payloads
+	ExitIPURL          string        // optional IP echo endpoint requested through each proxy; empty disables the exit IP probe
 }
 
+// durationModeTransferSize is the per-stream size requested in the time-based mode.
+const durationModeTransferSize = 1 << 30
+
 type serverMode int
 
 const (
@@ -93,6 +115,24 @@ func New(config *Config) (*SpeedTester, error) {
 	if config.Concurrent <= 0 {
 		config.Concurrent = 1
 	}
+	if config.TransferPolicy == "" {
+		config.TransferPolicy = TransferPolicyStrict
+	}
+	if config.MinSuccessRatio <= 0 || config.MinSuccessRatio > 1 {
+		config.MinSuccessRatio = 0.5
+	}
+	if config.PingCount <= 0 {
+		config.PingCount = 6
+	}
+	if config.PingInterval < 0 {
+		config.PingInterval = 0
+	}
+	if config.ProxyConcurrency <= 0 {
+		config.ProxyConcurrency = 1
+	}
+	if config.ServerConcurrency < 0 {
+		config.ServerConcurrency = 0
+	}
 	if config.DownloadSize < 0 {
 		config.DownloadSize = 100 * 1024 * 1024
 	}
@@ -107,10 +147,28 @@ func New(config *Config) (*SpeedTester, error) {
 	if err != nil {
 		return nil, err
 	}
-	if mode == SpeedModeFull && config.UploadSize <= 0 {
+	if config.ConcurrencyScaling {
+		config.ScalingStreams = normalizeStreamCounts(config.ScalingStreams)
+		if len(config.ScalingStreams) == 0 {
+			config.ScalingStreams = slices.Clone(defaultScalingStreams)
+		}
+	}
+	if config.SampleInterval <= 0 {
+		config.SampleInterval = 250 * time.Millisecond
+	}
+	if config.TestDuration < 0 {
+		config.TestDuration = 0
+	}
+	if mode == SpeedModeFull && config.UploadSize <= 0 && config.TestDuration == 0 {
 		return nil, fmt.Errorf("upload size must be positive when speed mode is %s", mode)
 	}
-	if target.mode == serverModeDirectDownload && mode == SpeedModeFull {
+	if config.PayloadType == "" {
+		config.PayloadType = PayloadZero
+	}
+	if config.SustainedDuration <= 0 {
+		config.SustainedDuration = 30 * time.Second
+	}
+	if target.mode == serverModeDirectDownload && (mode == SpeedModeFull || mode == SpeedModeSustained) {
 		mode = SpeedModeDownload
 	}
 	config.Mode = mode
@@ -160,7 +218,8 @@ func resolveServerTarget(rawURL string) (*serverTarget, error) {
 
 type CProxy struct {
 	constant.Proxy
-	Config map[string]any
+	Config   map[string]any
+	Provider string // name of the proxy provider the node came from; empty for inline proxies
 }
 
 type RawConfig struct {
@@ -190,12 +249,12 @@ func (st *SpeedTester) LoadProxies() (map[string]*CProxy, error) {
 			continue
 		}
 
-		rawCfg := &RawConfig{
-			Proxies: []map[string]any{},
-		}
-		if err := yaml.Unmarshal(body, rawCfg); err != nil {
+		rawCfg, format, err := parseRawConfig(body, configPath)
+		if err != nil {
 			return nil, fmt.Errorf("unable to parse config at path %s: %w, body: %s", configPath, err, body)
 		}
+		// 订阅链接和 sing-box 配置中的单个节点出错时只跳过该节点，Clash 配置保持原来的严格校验
+		lenient := format != convert.InputClash
 		proxies := make(map[string]*CProxy)
 		proxiesConfig := rawCfg.Proxies
 		providersConfig := rawCfg.Providers
@@ -203,10 +262,18 @@ func (st *SpeedTester) LoadProxies() (map[string]*CProxy, error) {
 		for i, config := range proxiesConfig {
 			proxy, err := adapter.ParseProxy(config)
 			if err != nil {
+				if lenient {
+					log.Printf("skip proxy %d of %s: %s", i, configPath, err)
+					continue
+				}
 				return nil, fmt.Errorf("proxy %d: %w", i, err)
 			}
 
 			if _, exist := proxies[proxy.Name()]; exist {
+				if lenient {
+					log.Printf("skip proxy %d of %s: duplicate name %s", i, configPath, proxy.Name())
+					continue
+				}
 				return nil, fmt.Errorf("proxy %s is the duplicate name", proxy.Name())
 			}
 			proxies[proxy.Name()] = &CProxy{Proxy: proxy, Config: config}
@@ -229,10 +296,8 @@ func (st *SpeedTester) LoadProxies() (map[string]*CProxy, error) {
 				log.Printf("failed to fetch config: %s", err)
 				continue
 			}
-			pdRawCfg := &RawConfig{
-				Proxies: []map[string]any{},
-			}
-			if err := yaml.Unmarshal(body, pdRawCfg); err != nil {
+			pdRawCfg, _, err := parseRawConfig(body, name)
+			if err != nil {
 				return nil, fmt.Errorf("unable to parse config: %w, body: %s", err, body)
 			}
 			pdProxies := make(map[string]map[string]any)
@@ -244,8 +309,9 @@ func (st *SpeedTester) LoadProxies() (map[string]*CProxy, error) {
 			}
 			for _, proxy := range pd.Proxies() {
 				proxies[fmt.Sprintf("[%s] %s", name, proxy.Name())] = &CProxy{
-					Proxy:  proxy,
-					Config: pdProxies[proxy.Name()],
+					Proxy:    proxy,
+					Config:   pdProxies[proxy.Name()],
+					Provider: name,
 				}
 			}
 		}
@@ -300,6 +366,27 @@ func (st *SpeedTester) LoadProxies() (map[string]*CProxy, error) {
 	return deduplicateProxiesByServerPort(filteredProxies), nil
 }
 
+// parseRawConfig parses a config body after detecting its format: Clash YAML, a plain or
+// base64 encoded share URI list, or sing-box outbounds. Entries of the latter formats that
+// cannot be converted are logged and skipped.
+func parseRawConfig(body []byte, source string) (*RawConfig, convert.InputFormat, error) {
+	format := convert.DetectFormat(body)
+	if format == convert.InputClash {
+		rawCfg := &RawConfig{
+			Proxies: []map[string]any{},
+		}
+		if err := yaml.Unmarshal(body, rawCfg); err != nil {
+			return nil, format, err
+		}
+		return rawCfg, format, nil
+	}
+	proxies, errs := convert.ParseProxies(body, format)
+	for _, err := range errs {
+		log.Printf("skip %s entry of %s: %s", format, source, err)
+	}
+	return &RawConfig{Proxies: proxies}, format, nil
+}
+
 func deduplicateProxiesByServerPort(proxies map[string]*CProxy) map[string]*CProxy {
 	if len(proxies) < 2 {
 		return proxies
@@ -340,27 +427,117 @@ func buildProxyServerPortKey(proxy *CProxy) (string, bool) {
 	return fmt.Sprintf("%s:%s", server, port), true
 }
 
-func (st *SpeedTester) TestProxies(proxies map[string]*CProxy, tester func(result *Result)) {
-	for name, proxy := range proxies {
-		tester(st.testProxy(name, proxy))
+// TestProxies tests proxies with a pool of ProxyConcurrency workers.
+// The tester callback is never invoked concurrently, so callers do not need extra locking.
+// Cancelling ctx aborts in-flight tests; results of interrupted tests are not reported.
+func (st *SpeedTester) TestProxies(ctx context.Context, proxies map[string]*CProxy, tester func(result *Result)) {
+	jobs := make(chan proxyJob)
+	limiter := newServerLimiter(st.config.ServerConcurrency)
+	workers := max(min(st.config.ProxyConcurrency, len(proxies)), 1)
+
+	var wg sync.WaitGroup
+	var testerMu sync.Mutex
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				release, err := limiter.acquire(ctx, job.serverKey)
+				if err != nil {
+					continue
+				}
+				result := st.testProxy(ctx, job.name, job.proxy)
+				result.TestedAt = time.Now()
+				release()
+				if ctx.Err() != nil {
+					continue
+				}
+
+				testerMu.Lock()
+				tester(result)
+				testerMu.Unlock()
+			}
+		}()
 	}
+
+dispatch:
+	for _, job := range buildProxyJobs(proxies) {
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
 }
 
 type Result struct {
-	ProxyName     string         `json:"proxy_name"`
-	ProxyType     string         `json:"proxy_type"`
-	ProxyConfig   map[string]any `json:"proxy_config"`
-	Latency       time.Duration  `json:"latency"`
-	Jitter        time.Duration  `json:"jitter"`
-	PacketLoss    float64        `json:"packet_loss"`
-	DownloadSize  float64        `json:"download_size"`
-	DownloadTime  time.Duration  `json:"download_time"`
-	DownloadSpeed float64        `json:"download_speed"`
-	DownloadError string         `json:"download_error"`
-	UploadSize    float64        `json:"upload_size"`
-	UploadTime    time.Duration  `json:"upload_time"`
-	UploadSpeed   float64        `json:"upload_speed"`
-	UploadError   string         `json:"upload_error"`
+	ProxyName       string          `json:"proxy_name"`
+	ProxyType       string          `json:"proxy_type"`
+	ProxyConfig     map[string]any  `json:"proxy_config"`
+	Provider        string          `json:"provider"`
+	Latency         time.Duration   `json:"latency"`
+	LatencyMin      time.Duration   `json:"latency_min"`
+	LatencyMedian   time.Duration   `json:"latency_median"`
+	LatencyP90      time.Duration   `json:"latency_p90"`
+	LatencyP99      time.Duration   `json:"latency_p99"`
+	Jitter          time.Duration   `json:"jitter"`
+	PacketLoss      float64         `json:"packet_loss"`
+	DownloadSize    float64         `json:"download_size"`
+	DownloadTime    time.Duration   `json:"download_time"`
+	DownloadSpeed   float64         `json:"download_speed"`
+	DownloadError   string          `json:"download_error"`
+	DownloadStats   ThroughputStats `json:"download_stats"`
+	DownloadStreams []StreamResult  `json:"download_streams"`
+	UploadSize      float64         `json:"upload_size"`
+	UploadTime      time.Duration   `json:"upload_time"`
+	UploadSpeed     float64         `json:"upload_speed"`
+	UploadError     string          `json:"upload_error"`
+	UploadStats     ThroughputStats `json:"upload_stats"`
+	UploadStreams   []StreamResult  `json:"upload_streams"`
+
+	// Phase breakdown of the first latency probe and the download body transfer.
+	ProxyDialTime    time.Duration `json:"proxy_dial_time"`
+	TLSHandshakeTime time.Duration `json:"tls_handshake_time"`
+	TTFB             time.Duration `json:"ttfb"`
+	TransferTime     time.Duration `json:"transfer_time"`
+
+	UDPTested     bool          `json:"udp_tested"`
+	UDPSupported  bool          `json:"udp_supported"`
+	UDPLatency    time.Duration `json:"udp_latency"`
+	UDPPacketLoss float64       `json:"udp_packet_loss"`
+	UDPError      string        `json:"udp_error"`
+
+	// LoadedLatency is measured while download streams run; BufferbloatDelta is its increase over Latency.
+	LoadedLatency    time.Duration `json:"loaded_latency"`
+	BufferbloatDelta time.Duration `json:"bufferbloat_delta"`
+
+	// Concurrency scaling: the single-stream speed against the best multi-stream speed.
+	SingleStreamSpeed float64       `json:"single_stream_speed"`
+	BestStreamSpeed   float64       `json:"best_stream_speed"`
+	BestStreamCount   int           `json:"best_stream_count"`
+	ScalingSteps      []ScalingStep `json:"scaling_steps"`
+
+	// Sustained transfer: Throttled is set when the last segment is much slower than the first,
+	// and ThrottleBytes/ThrottleAfter locate the breakpoint.
+	SustainedTested       bool          `json:"sustained_tested"`
+	SustainedInitialSpeed float64       `json:"sustained_initial_speed"`
+	SustainedFinalSpeed   float64       `json:"sustained_final_speed"`
+	Throttled             bool          `json:"throttled"`
+	ThrottleBytes         int64         `json:"throttle_bytes"`
+	ThrottleAfter         time.Duration `json:"throttle_after"`
+	SustainedError        string        `json:"sustained_error"`
+
+	// IntegrityError reports downloads whose content, length or request headers were altered in transit.
+	IntegrityError string `json:"integrity_error"`
+
+	// ExitIP is the address traffic leaves the proxy from, as reported by the IP echo endpoint.
+	ExitIP      string `json:"exit_ip"`
+	ExitIPError string `json:"exit_ip_error"`
+
+	// TestedAt is when the test of the node finished.
+	TestedAt time.Time `json:"tested_at"`
 }
 
 func (r *Result) FormatDownloadSpeed() string {
@@ -381,6 +558,33 @@ func (r *Result) FormatLatency() string {
 	return fmt.Sprintf("%dms", r.Latency.Milliseconds())
 }
 
+func (r *Result) FormatLatencyMin() string {
+	return formatPhaseDuration(r.LatencyMin)
+}
+
+func (r *Result) FormatLatencyMedian() string {
+	return formatPhaseDuration(r.LatencyMedian)
+}
+
+func (r *Result) FormatLatencyP90() string {
+	return formatPhaseDuration(r.LatencyP90)
+}
+
+func (r *Result) FormatLatencyP99() string {
+	return formatPhaseDuration(r.LatencyP99)
+}
+
+func (r *Result) FormatLoadedLatency() string {
+	return formatPhaseDuration(r.LoadedLatency)
+}
+
+func (r *Result) FormatBufferbloat() string {
+	if r.LoadedLatency == 0 || r.Latency == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%+dms", r.BufferbloatDelta.Milliseconds())
+}
+
 func (r *Result) FormatJitter() string {
 	if r.Jitter == 0 {
 		return "N/A"
@@ -388,6 +592,60 @@ func (r *Result) FormatJitter() string {
 	return fmt.Sprintf("%dms", r.Jitter.Milliseconds())
 }
 
+func (r *Result) FormatProxyDialTime() string {
+	return formatPhaseDuration(r.ProxyDialTime)
+}
+
+func (r *Result) FormatTLSHandshakeTime() string {
+	return formatPhaseDuration(r.TLSHandshakeTime)
+}
+
+func (r *Result) FormatTTFB() string {
+	return formatPhaseDuration(r.TTFB)
+}
+
+func (r *Result) FormatTransferTime() string {
+	return formatPhaseDuration(r.TransferTime)
+}
+
+func formatPhaseDuration(value time.Duration) string {
+	if value == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%dms", value.Milliseconds())
+}
+
+func (r *Result) FormatUDPSupport() string {
+	if !r.UDPTested {
+		return "N/A"
+	}
+	if !r.UDPSupported {
+		return "No"
+	}
+	return "Yes"
+}
+
+func (r *Result) FormatUDPLatency() string {
+	if r.UDPLatency == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%dms", r.UDPLatency.Milliseconds())
+}
+
+func (r *Result) FormatUDPPacketLoss() string {
+	if !r.UDPTested || !r.UDPSupported {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", r.UDPPacketLoss)
+}
+
+func (r *Result) FormatUDPError() string {
+	if r.UDPError == "" {
+		return "N/A"
+	}
+	return r.UDPError
+}
+
 func (r *Result) FormatPacketLoss() string {
 	return fmt.Sprintf("%.1f%%", r.PacketLoss)
 }
@@ -431,20 +689,45 @@ func formatSpeed(bytesPerSecond float64) string {
 	return fmt.Sprintf("%.2f%s", speed, units[unit])
 }
 
-func (st *SpeedTester) testProxy(name string, proxy *CProxy) *Result {
+func (st *SpeedTester) testProxy(ctx context.Context, name string, proxy *CProxy) *Result {
 	result := &Result{
 		ProxyName:   name,
 		ProxyType:   proxy.Type().String(),
 		ProxyConfig: proxy.Config,
+		Provider:    proxy.Provider,
 	}
 
 	// 1. 首先进行延迟测试
-	latencyResult := st.testLatency(proxy, st.config.MaxLatency)
+	latencyResult := st.testLatency(ctx, proxy, st.config.MaxLatency)
 	result.Latency = latencyResult.avgLatency
+	result.LatencyMin = latencyResult.minLatency
+	result.LatencyMedian = latencyResult.medianLatency
+	result.LatencyP90 = latencyResult.p90Latency
+	result.LatencyP99 = latencyResult.p99Latency
 	result.Jitter = latencyResult.jitter
 	result.PacketLoss = latencyResult.packetLoss
+	result.ProxyDialTime = latencyResult.phases.proxyDial
+	result.TLSHandshakeTime = latencyResult.phases.tlsHandshake
+	result.TTFB = latencyResult.phases.ttfb
+
+	if st.config.UDPServer != "" && result.PacketLoss < 100 && ctx.Err() == nil {
+		udpResult := st.testUDP(ctx, proxy)
+		result.UDPTested = true
+		result.UDPSupported = udpResult.supported
+		result.UDPLatency = udpResult.latency
+		result.UDPPacketLoss = udpResult.packetLoss
+		result.UDPError = udpResult.error
+	}
+
+	if st.config.ExitIPURL != "" && result.PacketLoss < 100 && ctx.Err() == nil {
+		exitIP, err := st.testExitIP(ctx, proxy)
+		result.ExitIP = exitIP
+		if err != nil {
+			result.ExitIPError = err.Error()
+		}
+	}
 
-	if st.mode.IsFast() || result.PacketLoss == 100 {
+	if st.mode.IsFast() || result.PacketLoss == 100 || ctx.Err() != nil {
 		return result
 	}
 	if st.config.OutputPath != "" && st.config.MaxPacketLoss < 100 && latencyResult.packetLoss > st.config.MaxPacketLoss {
@@ -458,52 +741,72 @@ func (st *SpeedTester) testProxy(name string, proxy *CProxy) *Result {
 
 	var wg sync.WaitGroup
 
-	downloadSummary := newTransferSummary()
 	var uploadSummary *transferSummary
 	if st.mode.UploadEnabled() {
 		uploadSummary = newTransferSummary()
 	}
 
+	transferTimeout := st.config.Timeout
 	downloadChunkSize := st.config.DownloadSize / st.config.Concurrent
+	uploadChunkSize := st.config.UploadSize / st.config.Concurrent
+	if st.config.TestDuration > 0 {
+		// The time window ends the transfer, so request more than any stream can move in it.
+		transferTimeout += st.config.TestDuration
+		downloadChunkSize = durationModeTransferSize
+		uploadChunkSize = durationModeTransferSize
+	}
 	if downloadChunkSize > 0 {
-		downloadResults := make(chan *downloadResult, st.config.Concurrent)
-
-		for i := 0; i < st.config.Concurrent; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				downloadResults <- st.testDownload(proxy, downloadChunkSize, st.config.Timeout)
-			}()
+		sampler := newThroughputSampler(st.config.SampleInterval)
+		sampler.start()
+
+		probeCtx, stopProbe := context.WithCancel(ctx)
+		loadedLatencies := make(chan []time.Duration, 1)
+		go func() {
+			loadedLatencies <- st.probeLoadedLatency(probeCtx, proxy)
+		}()
+
+		downloadSummary := st.runDownloadStreams(ctx, proxy, st.config.Concurrent, downloadChunkSize, transferTimeout, sampler)
+		result.DownloadStats = summarizeThroughput(sampler.finish(), st.config.SampleInterval)
+		stopProbe()
+		result.LoadedLatency = averageLatency(<-loadedLatencies)
+		if result.LoadedLatency > 0 && result.Latency > 0 {
+			result.BufferbloatDelta = result.LoadedLatency - result.Latency
 		}
-		wg.Wait()
 
-		for range st.config.Concurrent {
-			if dr := <-downloadResults; dr != nil {
-				downloadSummary.add(dr)
-			}
-		}
-		close(downloadResults)
-
-		result.DownloadSize, result.DownloadTime, result.DownloadSpeed, result.DownloadError = applyTransferSummary(downloadSummary)
+		result.DownloadSize, result.DownloadTime, result.DownloadSpeed, result.DownloadError = applyTransferSummary(downloadSummary, st.config.TransferPolicy, st.config.MinSuccessRatio)
+		result.DownloadStreams = downloadSummary.streamResults()
+		result.IntegrityError = strings.Join(downloadSummary.integrity, "; ")
+		result.TransferTime = downloadSummary.averageTransfer()
 
 		if st.config.OutputPath != "" && st.config.MinDownloadSpeed > 0 && result.DownloadSpeed < st.config.MinDownloadSpeed {
 			return result
 		}
+
+		if st.config.ConcurrencyScaling && ctx.Err() == nil {
+			applyScaling(result, st.testConcurrencyScaling(ctx, proxy))
+		}
+		if st.mode.SustainedEnabled() && ctx.Err() == nil {
+			applySustained(result, st.testSustained(ctx, proxy))
+		}
 	}
 
-	if st.mode.UploadEnabled() {
-		uploadChunkSize := st.config.UploadSize / st.config.Concurrent
+	if st.mode.UploadEnabled() && ctx.Err() == nil {
 		if uploadChunkSize > 0 {
 			uploadResults := make(chan *downloadResult, st.config.Concurrent)
+			sampler := newThroughputSampler(st.config.SampleInterval)
+			sampler.start()
 
 			for i := 0; i < st.config.Concurrent; i++ {
 				wg.Add(1)
 				go func() {
 					defer wg.Done()
-					uploadResults <- st.testUpload(proxy, uploadChunkSize, st.config.Timeout)
+					ur := st.testUpload(ctx, proxy, uploadChunkSize, transferTimeout, st.config.TestDuration, sampler)
+					ur.stream = i + 1
+					uploadResults <- ur
 				}()
 			}
 			wg.Wait()
+			result.UploadStats = summarizeThroughput(sampler.finish(), st.config.SampleInterval)
 
 			for i := 0; i < st.config.Concurrent; i++ {
 				if ur := <-uploadResults; ur != nil {
@@ -512,31 +815,65 @@ func (st *SpeedTester) testProxy(name string, proxy *CProxy) *Result {
 			}
 			close(uploadResults)
 
-			result.UploadSize, result.UploadTime, result.UploadSpeed, result.UploadError = applyTransferSummary(uploadSummary)
+			result.UploadSize, result.UploadTime, result.UploadSpeed, result.UploadError = applyTransferSummary(uploadSummary, st.config.TransferPolicy, st.config.MinSuccessRatio)
+			result.UploadStreams = uploadSummary.streamResults()
 		}
 	}
 
 	return result
 }
 
+// runDownloadStreams downloads size bytes on each of streams parallel connections and collects the results.
+func (st *SpeedTester) runDownloadStreams(ctx context.Context, proxy constant.Proxy, streams, size int, timeout time.Duration, sampler *throughputSampler) *transferSummary {
+	results := make(chan *downloadResult, streams)
+	var wg sync.WaitGroup
+	for i := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dr := st.testDownload(ctx, proxy, size, timeout, st.config.TestDuration, sampler)
+			dr.stream = i + 1
+			results <- dr
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	summary := newTransferSummary()
+	for dr := range results {
+		summary.add(dr)
+	}
+	return summary
+}
+
 type latencyResult struct {
-	avgLatency time.Duration
-	jitter     time.Duration
-	packetLoss float64
+	avgLatency    time.Duration
+	minLatency    time.Duration
+	medianLatency time.Duration
+	p90Latency    time.Duration
+	p99Latency    time.Duration
+	jitter        time.Duration
+	packetLoss    float64
+	phases        phaseTimings
 }
 
-func (st *SpeedTester) testLatency(proxy constant.Proxy, minLatency time.Duration) *latencyResult {
+func (st *SpeedTester) testLatency(ctx context.Context, proxy constant.Proxy, minLatency time.Duration) *latencyResult {
 	client := st.createClient(proxy, minLatency)
 	defer client.CloseIdleConnections()
 
-	latencies := make([]time.Duration, 0, 6)
+	latencies := make([]time.Duration, 0, st.config.PingCount)
 	failedPings := 0
+	var phases *phaseTimings
 
-	for range 6 {
-		time.Sleep(100 * time.Millisecond)
+	for range st.config.PingCount {
+		if err := sleepContext(ctx, st.config.PingInterval); err != nil {
+			failedPings++
+			continue
+		}
 
+		trace := newPhaseTrace()
 		start := time.Now()
-		req, err := http.NewRequest(http.MethodHead, st.downloadURL, nil)
+		req, err := http.NewRequestWithContext(trace.withContext(ctx), http.MethodHead, st.downloadURL, nil)
 		if err != nil {
 			failedPings++
 			continue
@@ -548,26 +885,63 @@ func (st *SpeedTester) testLatency(proxy constant.Proxy, minLatency time.Duratio
 		}
 		resp.Body.Close()
 		latencies = append(latencies, time.Since(start))
+		if phases == nil {
+			// Keep the first successful probe, the only one that dials a fresh connection.
+			timings := trace.timings()
+			phases = &timings
+		}
 	}
 
-	return calculateLatencyStats(latencies, failedPings)
+	result := calculateLatencyStats(latencies, failedPings)
+	if phases != nil {
+		result.phases = *phases
+	}
+	return result
 }
 
 type downloadResult struct {
-	error    string
-	bytes    int64
-	duration time.Duration
+	stream int
+	error  string
+	// integrityError reports tampering with a transfer that otherwise completed.
+	integrityError string
+	bytes          int64
+	start          time.Time
+	duration       time.Duration
+	transfer       time.Duration
+}
+
+// StreamResult is the outcome of one of the concurrent streams of a transfer.
+type StreamResult struct {
+	Stream   int           `json:"stream"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration"`
+	Speed    float64       `json:"speed"`
+	Error    string        `json:"error"`
+}
+
+func (s StreamResult) FormatSpeed() string {
+	if s.Error != "" {
+		return "failed"
+	}
+	return formatSpeed(s.Speed)
 }
 
 type transferSummary struct {
 	totalBytes    int64
-	totalDuration time.Duration
+	totalTransfer time.Duration
 	successCount  int
+	failureCount  int
+	firstStart    time.Time
+	lastEnd       time.Time
+	streams       []StreamResult
 	errors        []string
 	errorSeen     map[string]struct{}
+	integrity     []string
 }
 
-func applyTransferSummary(summary *transferSummary) (float64, time.Duration, float64, string) {
+// applyTransferSummary measures aggregate throughput over the wall-clock window from the first
+// stream start to the last stream end. The policy decides whether failed streams zero the speed.
+func applyTransferSummary(summary *transferSummary, policy TransferPolicy, minSuccessRatio float64) (float64, time.Duration, float64, string) {
 	if summary == nil {
 		return 0, 0, 0, ""
 	}
@@ -577,14 +951,15 @@ func applyTransferSummary(summary *transferSummary) (float64, time.Duration, flo
 	var errorMessage string
 	if summary.successCount > 0 {
 		size = float64(summary.totalBytes)
-		duration = summary.averageDuration()
+		duration = summary.wallClockDuration()
 		if duration > 0 {
 			speed = float64(summary.totalBytes) / duration.Seconds()
 		}
 	}
 	if len(summary.errors) > 0 {
 		errorMessage = strings.Join(summary.errors, "; ")
-		// If any transfer error is reported, treat the speed as zero.
+	}
+	if !policy.accepts(summary.successCount, summary.failureCount, minSuccessRatio) {
 		speed = 0
 	}
 	return size, duration, speed, errorMessage
@@ -600,13 +975,34 @@ func (s *transferSummary) add(result *downloadResult) {
 	if result == nil {
 		return
 	}
+	stream := StreamResult{
+		Stream:   result.stream,
+		Bytes:    result.bytes,
+		Duration: result.duration,
+		Error:    result.error,
+	}
+	if result.error == "" && result.duration > 0 {
+		stream.Speed = float64(result.bytes) / result.duration.Seconds()
+	}
+	s.streams = append(s.streams, stream)
+	if result.integrityError != "" && !slices.Contains(s.integrity, result.integrityError) {
+		s.integrity = append(s.integrity, result.integrityError)
+	}
+
 	if result.error != "" {
+		s.failureCount++
 		s.appendError(result.error)
 		return
 	}
 	s.totalBytes += result.bytes
-	s.totalDuration += result.duration
+	s.totalTransfer += result.transfer
 	s.successCount++
+	if s.firstStart.IsZero() || result.start.Before(s.firstStart) {
+		s.firstStart = result.start
+	}
+	if end := result.start.Add(result.duration); end.After(s.lastEnd) {
+		s.lastEnd = end
+	}
 }
 
 func (s *transferSummary) appendError(message string) {
@@ -623,14 +1019,30 @@ func (s *transferSummary) appendError(message string) {
 	s.errors = append(s.errors, message)
 }
 
-func (s *transferSummary) averageDuration() time.Duration {
+func (s *transferSummary) wallClockDuration() time.Duration {
+	if s.successCount == 0 {
+		return 0
+	}
+	return s.lastEnd.Sub(s.firstStart)
+}
+
+// streamResults returns the per-stream breakdown ordered by stream number.
+func (s *transferSummary) streamResults() []StreamResult {
+	streams := slices.Clone(s.streams)
+	slices.SortFunc(streams, func(a, b StreamResult) int {
+		return a.Stream - b.Stream
+	})
+	return streams
+}
+
+func (s *transferSummary) averageTransfer() time.Duration {
 	if s.successCount == 0 {
 		return 0
 	}
-	return s.totalDuration / time.Duration(s.successCount)
+	return s.totalTransfer / time.Duration(s.successCount)
 }
 
-func (st *SpeedTester) testDownload(proxy constant.Proxy, size int, timeout time.Duration) *downloadResult {
+func (st *SpeedTester) testDownload(ctx context.Context, proxy constant.Proxy, size int, timeout, window time.Duration, sampler *throughputSampler) *downloadResult {
 	client := st.createClient(proxy, timeout)
 	defer client.CloseIdleConnections()
 
@@ -641,8 +1053,21 @@ func (st *SpeedTester) testDownload(proxy constant.Proxy, size int, timeout time
 	} else {
 		downloadURL = fmt.Sprintf("%s/__down?bytes=%d", st.serverBaseURL, size)
 	}
+	// 仅自建测速服务支持带 seed 的确定性内容和请求头回显
+	verify := st.config.VerifyPayload && st.serverMode == serverModeDownloadServer
+	var seed uint64
+	if verify {
+		seed = rand.Uint64()
+		downloadURL = fmt.Sprintf("%s&seed=%d", downloadURL, seed)
+	} else if st.serverMode == serverModeDownloadServer && st.config.PayloadType == PayloadRandom {
+		downloadURL = fmt.Sprintf("%s&payload=%s", downloadURL, PayloadRandom)
+	}
 
-	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	windowCtx, cancel := transferContext(ctx, window)
+	defer cancel()
+
+	trace := newPhaseTrace()
+	req, err := http.NewRequestWithContext(trace.withContext(windowCtx), http.MethodGet, downloadURL, nil)
 	if err != nil {
 		return &downloadResult{
 			error: fmt.Sprintf("create download request for %s failed: %v", downloadURL, err),
@@ -651,6 +1076,13 @@ func (st *SpeedTester) testDownload(proxy constant.Proxy, size int, timeout time
 	if st.serverMode == serverModeDirectDownload && size > 0 {
 		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", size-1))
 	}
+	var sentHeader http.Header
+	if verify {
+		// Set the headers the transport would otherwise add, so the echo can be compared exactly.
+		req.Header.Set("User-Agent", defaultFetchConfigUA())
+		req.Header.Set("Accept-Encoding", "identity")
+		sentHeader = req.Header.Clone()
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return &downloadResult{
@@ -665,27 +1097,72 @@ func (st *SpeedTester) testDownload(proxy constant.Proxy, size int, timeout time
 		}
 	}
 
-	downloadBytes, _ := io.Copy(io.Discard, resp.Body)
-	return &downloadResult{
+	var sink io.Writer = io.Discard
+	var verifier *payloadVerifier
+	if verify {
+		verifier = newPayloadVerifier(seed)
+		sink = verifier
+	}
+	downloadBytes, err := io.Copy(sink, newCountingReader(resp.Body, sampler))
+	end := time.Now()
+	if err != nil && (downloadBytes == 0 || !isPartialTransfer(ctx, err)) {
+		return &downloadResult{
+			error: fmt.Sprintf("download body from %s interrupted after %d bytes: %v, spent %s", downloadURL, downloadBytes, err, end.Sub(start)),
+		}
+	}
+	result := &downloadResult{
 		bytes:    downloadBytes,
-		duration: time.Since(start),
+		start:    start,
+		duration: end.Sub(start),
+		transfer: elapsedBetween(trace.firstByteAt(), end),
 	}
+	if verify {
+		result.integrityError = checkDownloadIntegrity(resp, sentHeader, seed, verifier, int64(size), err == nil)
+	}
+	return result
+}
+
+func checkDownloadIntegrity(resp *http.Response, sentHeader http.Header, seed uint64, verifier *payloadVerifier, size int64, complete bool) string {
+	var problems []string
+	if resp.Header.Get(PayloadSeedHeader) != strconv.FormatUint(seed, 10) {
+		problems = append(problems, "server did not serve the seeded payload")
+	} else if problem := verifier.verify(size, complete); problem != "" {
+		problems = append(problems, problem)
+	}
+	if problem := checkHeaderEcho(sentHeader, resp); problem != "" {
+		problems = append(problems, problem)
+	}
+	return strings.Join(problems, "; ")
 }
 
-func (st *SpeedTester) testUpload(proxy constant.Proxy, size int, timeout time.Duration) *downloadResult {
+func (st *SpeedTester) testUpload(ctx context.Context, proxy constant.Proxy, size int, timeout, window time.Duration, sampler *throughputSampler) *downloadResult {
 	client := st.createClient(proxy, timeout)
 	defer client.CloseIdleConnections()
 
-	reader := NewZeroReader(size)
+	reader := NewPayloadReader(st.config.PayloadType, rand.Uint64(), size)
 	uploadURL := fmt.Sprintf("%s/__up", st.serverBaseURL)
 
+	windowCtx, cancel := transferContext(ctx, window)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(windowCtx, http.MethodPost, uploadURL, newCountingReader(reader, sampler))
+	if err != nil {
+		return &downloadResult{
+			error: fmt.Sprintf("create upload request for %s failed: %v", uploadURL, err),
+		}
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
 	start := time.Now()
-	resp, err := client.Post(
-		uploadURL,
-		"application/octet-stream",
-		reader,
-	)
+	resp, err := client.Do(req)
 	if err != nil {
+		if reader.WrittenBytes() > 0 && isPartialTransfer(ctx, err) {
+			return &downloadResult{
+				bytes:    reader.WrittenBytes(),
+				start:    start,
+				duration: time.Since(start),
+			}
+		}
 		return &downloadResult{
 			error: fmt.Sprintf("upload request to %s failed: %v, spent %s", uploadURL, err, time.Since(start)),
 		}
@@ -700,10 +1177,32 @@ func (st *SpeedTester) testUpload(proxy constant.Proxy, size int, timeout time.D
 
 	return &downloadResult{
 		bytes:    reader.WrittenBytes(),
+		start:    start,
 		duration: time.Since(start),
 	}
 }
 
+// transferContext bounds a single transfer to window when a time-based transfer is requested.
+func transferContext(ctx context.Context, window time.Duration) (context.Context, context.CancelFunc) {
+	if window <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, window)
+}
+
+// isPartialTransfer reports whether err only cut a transfer short, either by the test window
+// or by the client timeout, so the bytes moved until then still count. Cancelling the run does not.
+func isPartialTransfer(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func (st *SpeedTester) createClient(proxy constant.Proxy, timeout time.Duration) *http.Client {
 	return &http.Client{
 		Timeout: timeout,
@@ -717,24 +1216,46 @@ func (st *SpeedTester) createClient(proxy constant.Proxy, timeout time.Duration)
 				if port, err := strconv.ParseUint(port, 10, 16); err == nil {
 					u16Port = uint16(port)
 				}
-				return proxy.DialContext(ctx, &constant.Metadata{
+				dialDone := traceDial(ctx, network, addr)
+				conn, err := proxy.DialContext(ctx, &constant.Metadata{
 					Host:    host,
 					DstPort: u16Port,
 				})
+				dialDone(err)
+				return conn, err
 			},
 		},
 	}
 }
 
+func sleepContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 func calculateLatencyStats(latencies []time.Duration, failedPings int) *latencyResult {
-	result := &latencyResult{
-		packetLoss: float64(failedPings) / 6.0 * 100,
+	result := &latencyResult{}
+	if samples := len(latencies) + failedPings; samples > 0 {
+		result.packetLoss = float64(failedPings) / float64(samples) * 100
 	}
 
 	if len(latencies) == 0 {
 		return result
 	}
 
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	result.minLatency = sorted[0]
+	result.medianLatency = medianDuration(sorted)
+	result.p90Latency = percentileDuration(sorted, 90)
+	result.p99Latency = percentileDuration(sorted, 99)
+
 	// 计算平均延迟
 	var total time.Duration
 	for _, l := range latencies {
@@ -754,6 +1275,21 @@ func calculateLatencyStats(latencies []time.Duration, failedPings int) *latencyR
 	return result
 }
 
+// medianDuration expects sorted, non-empty input.
+func medianDuration(sorted []time.Duration) time.Duration {
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// percentileDuration uses the nearest-rank method and expects sorted, non-empty input.
+func percentileDuration(sorted []time.Duration, percentile float64) time.Duration {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	return sorted[max(min(rank-1, len(sorted)-1), 0)]
+}
+
 func convertMappedIPv6ToIPv4(server string) string {
 	ip := net.ParseIP(server)
 	if ip == nil {
@@ -1,6 +1,11 @@
 package speedtester
 
 import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -26,20 +31,79 @@ func TestTransferSummaryAdd(t *testing.T) {
 		t.Fatalf("expected duplicate errors to be deduplicated, got %d", len(summary.errors))
 	}
 
-	summary.add(&downloadResult{bytes: 100, duration: time.Second})
-	summary.add(&downloadResult{bytes: 50, duration: 2 * time.Second})
+	base := time.Now()
+	summary.add(&downloadResult{stream: 2, bytes: 100, start: base.Add(500 * time.Millisecond), duration: time.Second})
+	summary.add(&downloadResult{stream: 1, bytes: 50, start: base, duration: 2 * time.Second})
 
 	if summary.successCount != 2 {
 		t.Fatalf("expected successCount to be 2, got %d", summary.successCount)
 	}
+	if summary.failureCount != 2 {
+		t.Fatalf("expected failureCount to be 2, got %d", summary.failureCount)
+	}
 	if summary.totalBytes != 150 {
 		t.Fatalf("expected totalBytes to be 150, got %d", summary.totalBytes)
 	}
-	if summary.totalDuration != 3*time.Second {
-		t.Fatalf("expected totalDuration to be 3s, got %v", summary.totalDuration)
+	if summary.wallClockDuration() != 2*time.Second {
+		t.Fatalf("expected wallClockDuration to be 2s, got %v", summary.wallClockDuration())
+	}
+
+	streams := summary.streamResults()
+	if len(streams) != 4 {
+		t.Fatalf("expected 4 streams, got %d", len(streams))
+	}
+	if streams[2].Stream != 1 || streams[2].Speed != 25 {
+		t.Fatalf("expected stream 1 at 25 B/s, got %+v", streams[2])
 	}
-	if summary.averageDuration() != 1500*time.Millisecond {
-		t.Fatalf("expected averageDuration to be 1.5s, got %v", summary.averageDuration())
+	if streams[3].Stream != 2 || streams[3].Speed != 100 {
+		t.Fatalf("expected stream 2 at 100 B/s, got %+v", streams[3])
+	}
+}
+
+func TestApplyTransferSummaryPolicies(t *testing.T) {
+	base := time.Now()
+	summary := newTransferSummary()
+	summary.add(&downloadResult{stream: 1, bytes: 200, start: base, duration: time.Second})
+	summary.add(&downloadResult{stream: 2, bytes: 200, start: base.Add(time.Second), duration: time.Second})
+	summary.add(&downloadResult{stream: 3, error: "stream failed"})
+
+	tests := []struct {
+		name     string
+		policy   TransferPolicy
+		ratio    float64
+		expected float64
+	}{
+		{name: "strict", policy: TransferPolicyStrict, ratio: 0.5, expected: 0},
+		{name: "best effort", policy: TransferPolicyBestEffort, ratio: 0.5, expected: 200},
+		{name: "min success met", policy: TransferPolicyMinSuccess, ratio: 0.5, expected: 200},
+		{name: "min success missed", policy: TransferPolicyMinSuccess, ratio: 0.9, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, duration, speed, errorMessage := applyTransferSummary(summary, tt.policy, tt.ratio)
+			if size != 400 {
+				t.Fatalf("expected size 400, got %v", size)
+			}
+			if duration != 2*time.Second {
+				t.Fatalf("expected wall-clock duration 2s, got %v", duration)
+			}
+			if speed != tt.expected {
+				t.Fatalf("expected speed %v, got %v", tt.expected, speed)
+			}
+			if errorMessage != "stream failed" {
+				t.Fatalf("expected error message to be kept, got %q", errorMessage)
+			}
+		})
+	}
+}
+
+func TestParseTransferPolicy(t *testing.T) {
+	policy, err := ParseTransferPolicy(" Best-Effort ")
+	if err != nil || policy != TransferPolicyBestEffort {
+		t.Fatalf("expected best-effort, got %q (%v)", policy, err)
+	}
+	if _, err := ParseTransferPolicy("lenient"); err == nil {
+		t.Fatalf("expected unsupported policy to fail")
 	}
 }
 
@@ -129,3 +193,114 @@ func TestDeduplicateProxies(t *testing.T) {
 		}
 	})
 }
+
+func TestSleepContext(t *testing.T) {
+	if err := sleepContext(context.Background(), time.Millisecond); err != nil {
+		t.Fatalf("expected sleep to finish without error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if err := sleepContext(ctx, time.Minute); err == nil {
+		t.Fatalf("expected cancelled sleep to return an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected cancelled sleep to return immediately, took %s", elapsed)
+	}
+}
+
+func TestCalculateLatencyStats(t *testing.T) {
+	latencies := []time.Duration{
+		50 * time.Millisecond,
+		10 * time.Millisecond,
+		40 * time.Millisecond,
+		20 * time.Millisecond,
+		30 * time.Millisecond,
+		100 * time.Millisecond,
+		70 * time.Millisecond,
+		60 * time.Millisecond,
+		90 * time.Millisecond,
+		80 * time.Millisecond,
+	}
+	result := calculateLatencyStats(latencies, 0)
+	if result.packetLoss != 0 {
+		t.Fatalf("expected no packet loss, got %.2f", result.packetLoss)
+	}
+	if result.avgLatency != 55*time.Millisecond {
+		t.Fatalf("expected average 55ms, got %s", result.avgLatency)
+	}
+	if result.minLatency != 10*time.Millisecond {
+		t.Fatalf("expected min 10ms, got %s", result.minLatency)
+	}
+	if result.medianLatency != 55*time.Millisecond {
+		t.Fatalf("expected median 55ms, got %s", result.medianLatency)
+	}
+	if result.p90Latency != 90*time.Millisecond {
+		t.Fatalf("expected p90 90ms, got %s", result.p90Latency)
+	}
+	if result.p99Latency != 100*time.Millisecond {
+		t.Fatalf("expected p99 100ms, got %s", result.p99Latency)
+	}
+	if latencies[0] != 50*time.Millisecond {
+		t.Fatalf("expected input latencies to keep their order")
+	}
+}
+
+func TestCalculateLatencyStatsPacketLoss(t *testing.T) {
+	result := calculateLatencyStats([]time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond}, 7)
+	if result.packetLoss != 70 {
+		t.Fatalf("expected packet loss from 10 samples to be 70%%, got %.2f", result.packetLoss)
+	}
+	if result.medianLatency != 20*time.Millisecond {
+		t.Fatalf("expected median 20ms, got %s", result.medianLatency)
+	}
+
+	allFailed := calculateLatencyStats(nil, 4)
+	if allFailed.packetLoss != 100 {
+		t.Fatalf("expected 100%% packet loss when all probes fail, got %.2f", allFailed.packetLoss)
+	}
+	if allFailed.avgLatency != 0 || allFailed.p99Latency != 0 {
+		t.Fatalf("expected zero latency stats when all probes fail")
+	}
+}
+
+func TestLoadProxiesFromSubscription(t *testing.T) {
+	links := strings.Join([]string{
+		"# remarks: updated daily",
+		"ssr://unsupported",
+		"trojan://pass@a.com:443?sni=a.com#tj",
+		"vless://id@a.com:99999#bad-port",
+		"ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.4:8388#ss",
+		"ss://YWVzLTEyOC1nY206cGFzcw@1.2.3.5:8388#ss",
+	}, "\n")
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "uri list", body: links},
+		{name: "base64", body: base64.StdEncoding.EncodeToString([]byte(links))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sub.txt")
+			if err := os.WriteFile(path, []byte(tt.body), 0o644); err != nil {
+				t.Fatalf("write subscription failed: %v", err)
+			}
+			st, err := New(&Config{ConfigPaths: path, ServerURL: "https://example.com", Concurrent: 1})
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			proxies, err := st.LoadProxies()
+			if err != nil {
+				t.Fatalf("LoadProxies failed: %v", err)
+			}
+			if len(proxies) != 2 || proxies["tj"] == nil || proxies["ss"] == nil {
+				t.Fatalf("expected tj and ss to load and the bad and duplicate lines to be skipped, got %v", proxies)
+			}
+			if server := proxies["ss"].Config["server"]; server != "1.2.3.4" {
+				t.Fatalf("expected the first ss line to win, got %v", server)
+			}
+		})
+	}
+}
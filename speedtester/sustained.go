@@ -0,0 +1,133 @@
+package speedtester
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/metacubex/mihomo/constant"
+)
+
+const (
+	// sustainedTransferSize is requested so that the window, not the payload, ends the transfer.
+	// It is capped to int, so 32-bit builds request just under 2GiB.
+	sustainedTransferSize = min(1<<40, math.MaxInt)
+	// throttleSegmentRatio is the share of samples compared at the start and the end of the transfer.
+	throttleSegmentRatio = 0.2
+	// throttleSpeedRatio flags a throttle when the last segment falls below this share of the first.
+	throttleSpeedRatio = 0.5
+	// throttleMinSamples is the fewest samples that still give distinct first and last segments.
+	throttleMinSamples = 4
+)
+
+type sustainedResult struct {
+	initialSpeed float64
+	finalSpeed   float64
+	throttled    bool
+	breakBytes   int64
+	breakAfter   time.Duration
+	error        string
+}
+
+// testSustained streams a single connection for SustainedDuration. Throttling is applied per
+// connection after some volume, so one long stream shows it where the short multi-stream test cannot.
+func (st *SpeedTester) testSustained(ctx context.Context, proxy constant.Proxy) *sustainedResult {
+	sampler := newThroughputSampler(st.config.SampleInterval)
+	sampler.start()
+	dr := st.testDownload(ctx, proxy, sustainedTransferSize, st.config.Timeout+st.config.SustainedDuration, st.config.SustainedDuration, sampler)
+	samples := sampler.finish()
+	if dr.error != "" {
+		return &sustainedResult{error: dr.error}
+	}
+	return detectThrottle(samples, st.config.SampleInterval)
+}
+
+// detectThrottle compares the average speed of the first and last segments of a sampled transfer.
+// The breakpoint is the first sample from which the average speed stays below the throttle threshold.
+func detectThrottle(samples []float64, interval time.Duration) *sustainedResult {
+	// Intervals before the first byte are connection setup and would drag the first segment down.
+	firstByte := 0
+	for firstByte < len(samples) && samples[firstByte] == 0 {
+		firstByte++
+	}
+	active := samples[firstByte:]
+	if len(active) < throttleMinSamples {
+		return &sustainedResult{error: fmt.Sprintf("too few samples to detect throttling: %d", len(active))}
+	}
+
+	segment := max(int(float64(len(active))*throttleSegmentRatio), 1)
+	result := &sustainedResult{
+		initialSpeed: averageSpeed(active[:segment]),
+		finalSpeed:   averageSpeed(active[len(active)-segment:]),
+	}
+	threshold := result.initialSpeed * throttleSpeedRatio
+	if result.initialSpeed <= 0 || result.finalSpeed >= threshold {
+		return result
+	}
+
+	result.throttled = true
+	transferred := averageSpeed(active[:segment]) * float64(segment) * interval.Seconds()
+	for i := segment; i < len(active); i++ {
+		if active[i] < threshold && averageSpeed(active[i:]) < threshold {
+			result.breakBytes = int64(transferred)
+			result.breakAfter = time.Duration(firstByte+i) * interval
+			break
+		}
+		transferred += active[i] * interval.Seconds()
+	}
+	if result.breakAfter == 0 {
+		result.breakBytes = int64(transferred)
+		result.breakAfter = time.Duration(len(samples)) * interval
+	}
+	return result
+}
+
+func averageSpeed(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var total float64
+	for _, sample := range samples {
+		total += sample
+	}
+	return total / float64(len(samples))
+}
+
+func applySustained(result *Result, sustained *sustainedResult) {
+	result.SustainedTested = true
+	result.SustainedInitialSpeed = sustained.initialSpeed
+	result.SustainedFinalSpeed = sustained.finalSpeed
+	result.Throttled = sustained.throttled
+	result.ThrottleBytes = sustained.breakBytes
+	result.ThrottleAfter = sustained.breakAfter
+	result.SustainedError = sustained.error
+}
+
+func (r *Result) FormatThrottle() string {
+	if !r.SustainedTested || r.SustainedError != "" {
+		return "N/A"
+	}
+	if !r.Throttled {
+		return "No"
+	}
+	return fmt.Sprintf("after %s (%s)", formatBytes(r.ThrottleBytes), r.ThrottleAfter.Round(time.Second))
+}
+
+func (r *Result) FormatSustainedSpeed() string {
+	if !r.SustainedTested || r.SustainedError != "" {
+		return "N/A"
+	}
+	return fmt.Sprintf("%s -> %s", formatSpeed(r.SustainedInitialSpeed), formatSpeed(r.SustainedFinalSpeed))
+}
+
+func formatBytes(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	unit := 0
+	value := float64(size)
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.2f%s", value, units[unit])
+}
@@ -0,0 +1,107 @@
+package speedtester
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDetectThrottleFindsBreakpoint(t *testing.T) {
+	interval := time.Second
+	samples := []float64{0, 100, 100, 100, 100, 100, 20, 10, 10, 10, 10}
+	result := detectThrottle(samples, interval)
+	if result.error != "" {
+		t.Fatalf("unexpected error: %s", result.error)
+	}
+	if !result.throttled {
+		t.Fatalf("expected throttling to be detected")
+	}
+	if result.initialSpeed != 100 || result.finalSpeed != 10 {
+		t.Fatalf("expected first/last segment speeds 100/10, got %v/%v", result.initialSpeed, result.finalSpeed)
+	}
+	if result.breakBytes != 500 {
+		t.Fatalf("expected breakpoint after 500 bytes, got %d", result.breakBytes)
+	}
+	if result.breakAfter != 6*time.Second {
+		t.Fatalf("expected breakpoint after 6s, got %s", result.breakAfter)
+	}
+}
+
+func TestDetectThrottleSteadyTransfer(t *testing.T) {
+	result := detectThrottle([]float64{90, 100, 110, 95, 105, 100, 80, 100}, time.Second)
+	if result.throttled {
+		t.Fatalf("expected a steady transfer not to be flagged, got %+v", result)
+	}
+	if result.breakBytes != 0 || result.breakAfter != 0 {
+		t.Fatalf("expected no breakpoint, got %d bytes after %s", result.breakBytes, result.breakAfter)
+	}
+}
+
+func TestDetectThrottleTooFewSamples(t *testing.T) {
+	result := detectThrottle([]float64{0, 0, 100, 10}, time.Second)
+	if result.error == "" {
+		t.Fatalf("expected an error for too few samples")
+	}
+	if (&Result{SustainedTested: true, SustainedError: result.error}).FormatThrottle() != "N/A" {
+		t.Fatalf("expected failed sustained test to format as N/A")
+	}
+}
+
+func TestSustainedDetectsServerThrottle(t *testing.T) {
+	burst := make([]byte, 512*1024)
+	chunk := make([]byte, 512)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		w.Write(burst)
+		flusher.Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	st := &SpeedTester{
+		config: &Config{
+			Timeout:           5 * time.Second,
+			SampleInterval:    100 * time.Millisecond,
+			SustainedDuration: time.Second,
+		},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	result := &Result{}
+	applySustained(result, st.testSustained(context.Background(), newDirectProxy()))
+	if result.SustainedError != "" {
+		t.Fatalf("unexpected sustained error: %s", result.SustainedError)
+	}
+	if !result.Throttled {
+		t.Fatalf("expected the throttled server to be flagged, got %s", result.FormatSustainedSpeed())
+	}
+	if result.ThrottleBytes < int64(len(burst))/2 {
+		t.Fatalf("expected the breakpoint after the burst, got %d bytes", result.ThrottleBytes)
+	}
+}
+
+func TestFormatThrottle(t *testing.T) {
+	if (&Result{}).FormatThrottle() != "N/A" {
+		t.Fatalf("expected untested node to format as N/A")
+	}
+	if (&Result{SustainedTested: true}).FormatThrottle() != "No" {
+		t.Fatalf("expected unthrottled node to format as No")
+	}
+	result := &Result{SustainedTested: true, Throttled: true, ThrottleBytes: 100 * 1024 * 1024, ThrottleAfter: 12 * time.Second}
+	if result.FormatThrottle() != "after 100.00MB (12s)" {
+		t.Fatalf("unexpected throttle format %q", result.FormatThrottle())
+	}
+}
@@ -0,0 +1,100 @@
+package speedtester
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// phaseTrace records request phase timestamps through net/http/httptrace.
+// DNS for the target is resolved by the proxy, so it is part of the proxy dial phase.
+type phaseTrace struct {
+	mu           sync.Mutex
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+type phaseTimings struct {
+	proxyDial    time.Duration
+	tlsHandshake time.Duration
+	ttfb         time.Duration
+}
+
+func newPhaseTrace() *phaseTrace {
+	return &phaseTrace{}
+}
+
+func (t *phaseTrace) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.mark(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(&t.tlsDone)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mark(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+		},
+	})
+}
+
+func (t *phaseTrace) mark(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*field = time.Now()
+}
+
+func (t *phaseTrace) firstByteAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.firstByte
+}
+
+func (t *phaseTrace) timings() phaseTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return phaseTimings{
+		proxyDial:    elapsedBetween(t.connectStart, t.connectDone),
+		tlsHandshake: elapsedBetween(t.tlsStart, t.tlsDone),
+		ttfb:         elapsedBetween(t.wroteRequest, t.firstByte),
+	}
+}
+
+func elapsedBetween(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// traceDial reports a proxy dial to the request's ClientTrace. The transport only
+// fires ConnectStart/ConnectDone for its built-in dialer, not for a custom DialContext.
+func traceDial(ctx context.Context, network, addr string) func(error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		return func(error) {}
+	}
+	if trace.ConnectStart != nil {
+		trace.ConnectStart(network, addr)
+	}
+	return func(err error) {
+		if trace.ConnectDone != nil {
+			trace.ConnectDone(network, addr, err)
+		}
+	}
+}
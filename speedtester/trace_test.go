@@ -0,0 +1,70 @@
+package speedtester
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPhaseTraceRecordsTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dialDone := traceDial(ctx, network, addr)
+				conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+				dialDone(err)
+				return conn, err
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	trace := newPhaseTrace()
+	req, err := http.NewRequestWithContext(trace.withContext(context.Background()), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("create request failed: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	timings := trace.timings()
+	if timings.proxyDial <= 0 {
+		t.Fatalf("expected proxy dial time to be recorded, got %s", timings.proxyDial)
+	}
+	if timings.tlsHandshake <= 0 {
+		t.Fatalf("expected TLS handshake time to be recorded, got %s", timings.tlsHandshake)
+	}
+	if timings.ttfb < 20*time.Millisecond {
+		t.Fatalf("expected TTFB to include server delay, got %s", timings.ttfb)
+	}
+	if trace.firstByteAt().IsZero() {
+		t.Fatalf("expected first byte timestamp to be recorded")
+	}
+}
+
+func TestElapsedBetween(t *testing.T) {
+	start := time.Now()
+	if elapsedBetween(time.Time{}, start) != 0 {
+		t.Fatalf("expected zero start to yield zero duration")
+	}
+	if elapsedBetween(start, start.Add(-time.Second)) != 0 {
+		t.Fatalf("expected end before start to yield zero duration")
+	}
+	if elapsedBetween(start, start.Add(time.Second)) != time.Second {
+		t.Fatalf("expected one second between timestamps")
+	}
+}
@@ -0,0 +1,116 @@
+package speedtester
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/metacubex/mihomo/adapter"
+	"github.com/metacubex/mihomo/adapter/outbound"
+	"github.com/metacubex/mihomo/constant"
+)
+
+func newDirectProxy() constant.Proxy {
+	return adapter.NewProxy(outbound.NewDirect())
+}
+
+// newTrickleServer streams 1KB chunks every interval until the client goes away.
+func newTrickleServer(t *testing.T, interval time.Duration) *httptest.Server {
+	t.Helper()
+	chunk := make([]byte, 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadDurationModeCountsWindowBytes(t *testing.T) {
+	server := newTrickleServer(t, 5*time.Millisecond)
+	st := &SpeedTester{
+		config:        &Config{TestDuration: 200 * time.Millisecond},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	result := st.testDownload(context.Background(), newDirectProxy(), durationModeTransferSize, 5*time.Second, st.config.TestDuration, nil)
+	if result.error != "" {
+		t.Fatalf("expected the time window to end the download without error, got %q", result.error)
+	}
+	if result.bytes <= 0 {
+		t.Fatalf("expected bytes moved within the window to count, got %d", result.bytes)
+	}
+	if result.duration < 200*time.Millisecond || result.duration > 2*time.Second {
+		t.Fatalf("expected duration close to the 200ms window, got %s", result.duration)
+	}
+}
+
+func TestDownloadClientTimeoutKeepsPartialBytes(t *testing.T) {
+	server := newTrickleServer(t, 5*time.Millisecond)
+	st := &SpeedTester{
+		config:        &Config{},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	result := st.testDownload(context.Background(), newDirectProxy(), 1<<30, 200*time.Millisecond, 0, nil)
+	if result.error != "" {
+		t.Fatalf("expected client timeout to keep partial bytes, got error %q", result.error)
+	}
+	if result.bytes <= 0 {
+		t.Fatalf("expected partial bytes to count, got %d", result.bytes)
+	}
+}
+
+func TestDownloadCancelledRunIsNotPartial(t *testing.T) {
+	server := newTrickleServer(t, 5*time.Millisecond)
+	st := &SpeedTester{
+		config:        &Config{},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	result := st.testDownload(ctx, newDirectProxy(), 1<<30, 5*time.Second, 0, nil)
+	if result.error == "" {
+		t.Fatalf("expected a cancelled run to report an error, got %d bytes", result.bytes)
+	}
+}
+
+func TestUploadDurationModeCountsWindowBytes(t *testing.T) {
+	server := newTrickleServer(t, 5*time.Millisecond)
+	st := &SpeedTester{
+		config:        &Config{TestDuration: 200 * time.Millisecond},
+		serverMode:    serverModeDownloadServer,
+		serverBaseURL: server.URL,
+	}
+
+	result := st.testUpload(context.Background(), newDirectProxy(), durationModeTransferSize, 5*time.Second, st.config.TestDuration, nil)
+	if result.error != "" {
+		t.Fatalf("expected the time window to end the upload without error, got %q", result.error)
+	}
+	if result.bytes <= 0 {
+		t.Fatalf("expected uploaded bytes within the window to count, got %d", result.bytes)
+	}
+}
@@ -0,0 +1,133 @@
+package speedtester
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/metacubex/mihomo/constant"
+)
+
+const (
+	udpProbeCount   = 5
+	udpProbeTimeout = time.Second
+	udpProbePrefix  = "clash-speedtest-udp-"
+)
+
+type udpResult struct {
+	supported  bool
+	latency    time.Duration
+	packetLoss float64
+	error      string
+}
+
+// testUDP sends numbered datagrams through the proxy to a UDP echo server and waits for each echo.
+// Proxies whose type cannot relay UDP are reported as unsupported instead of failed.
+func (st *SpeedTester) testUDP(ctx context.Context, proxy constant.Proxy) *udpResult {
+	if !proxy.SupportUDP() {
+		return &udpResult{}
+	}
+
+	addr, err := resolveUDPServer(ctx, st.config.UDPServer)
+	if err != nil {
+		return &udpResult{supported: true, packetLoss: 100, error: err.Error()}
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, st.config.Timeout)
+	defer cancel()
+	conn, err := proxy.ListenPacketContext(dialCtx, &constant.Metadata{
+		NetWork: constant.UDP,
+		DstIP:   addr.Addr(),
+		DstPort: addr.Port(),
+	})
+	if err != nil {
+		return &udpResult{
+			supported:  true,
+			packetLoss: 100,
+			error:      fmt.Sprintf("udp relay to %s failed: %v", addr, err),
+		}
+	}
+	defer conn.Close()
+
+	// Closing the socket unblocks a pending read when the run is cancelled.
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	result := probeUDP(conn, net.UDPAddrFromAddrPort(addr), udpProbeCount, udpProbeTimeout)
+	result.supported = true
+	return result
+}
+
+func resolveUDPServer(ctx context.Context, server string) (netip.AddrPort, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("parse udp server %q failed: %w", server, err)
+	}
+	udpAddr, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(udpAddr) == 0 {
+		return netip.AddrPort{}, fmt.Errorf("resolve udp server %q failed: %v", server, err)
+	}
+	portNumber, err := net.LookupPort("udp", port)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("parse udp server port %q failed: %w", port, err)
+	}
+	return netip.AddrPortFrom(udpAddr[0].Unmap(), uint16(portNumber)), nil
+}
+
+func probeUDP(conn net.PacketConn, addr net.Addr, count int, timeout time.Duration) *udpResult {
+	latencies := make([]time.Duration, 0, count)
+	var lastError string
+	buffer := make([]byte, 2048)
+
+	for seq := range count {
+		payload := []byte(fmt.Sprintf("%s%d", udpProbePrefix, seq))
+		start := time.Now()
+		if _, err := conn.WriteTo(payload, addr); err != nil {
+			lastError = fmt.Sprintf("udp write to %s failed: %v", addr, err)
+			continue
+		}
+		deadline := start.Add(timeout)
+		for {
+			if err := conn.SetReadDeadline(deadline); err != nil {
+				lastError = fmt.Sprintf("udp set deadline failed: %v", err)
+				break
+			}
+			n, _, err := conn.ReadFrom(buffer)
+			if err != nil {
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					lastError = fmt.Sprintf("udp read from %s failed: %v", addr, err)
+				}
+				break
+			}
+			// Drop late echoes of earlier probes and keep waiting for this one.
+			if bytes.Equal(buffer[:n], payload) {
+				latencies = append(latencies, time.Since(start))
+				break
+			}
+		}
+	}
+
+	result := &udpResult{
+		packetLoss: float64(count-len(latencies)) / float64(count) * 100,
+	}
+	if len(latencies) == 0 {
+		result.error = lastError
+		if result.error == "" {
+			result.error = fmt.Sprintf("no udp echo from %s within %s", addr, timeout)
+		}
+		return result
+	}
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	result.latency = total / time.Duration(len(latencies))
+	return result
+}
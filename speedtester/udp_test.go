@@ -0,0 +1,135 @@
+package speedtester
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startUDPEcho(t *testing.T, drop func(payload []byte) bool) net.PacketConn {
+	t.Helper()
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	go func() {
+		buffer := make([]byte, 2048)
+		for {
+			n, remote, err := server.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			if drop != nil && drop(buffer[:n]) {
+				continue
+			}
+			server.WriteTo(buffer[:n], remote)
+		}
+	}()
+	t.Cleanup(func() {
+		server.Close()
+	})
+	return server
+}
+
+func TestProbeUDP(t *testing.T) {
+	server := startUDPEcho(t, nil)
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	defer client.Close()
+
+	result := probeUDP(client, server.LocalAddr(), 4, time.Second)
+	if result.packetLoss != 0 {
+		t.Fatalf("expected no packet loss, got %.1f%%", result.packetLoss)
+	}
+	if result.latency <= 0 {
+		t.Fatalf("expected positive udp latency, got %s", result.latency)
+	}
+	if result.error != "" {
+		t.Fatalf("expected no error, got %q", result.error)
+	}
+}
+
+func TestProbeUDPPacketLoss(t *testing.T) {
+	server := startUDPEcho(t, func(payload []byte) bool {
+		return strings.HasSuffix(string(payload), "-1") || strings.HasSuffix(string(payload), "-3")
+	})
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	defer client.Close()
+
+	result := probeUDP(client, server.LocalAddr(), 4, 100*time.Millisecond)
+	if result.packetLoss != 50 {
+		t.Fatalf("expected 50%% packet loss, got %.1f%%", result.packetLoss)
+	}
+	if result.latency <= 0 {
+		t.Fatalf("expected latency from the echoed probes, got %s", result.latency)
+	}
+}
+
+func TestProbeUDPNoEcho(t *testing.T) {
+	server := startUDPEcho(t, func([]byte) bool { return true })
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp failed: %v", err)
+	}
+	defer client.Close()
+
+	result := probeUDP(client, server.LocalAddr(), 2, 50*time.Millisecond)
+	if result.packetLoss != 100 {
+		t.Fatalf("expected 100%% packet loss, got %.1f%%", result.packetLoss)
+	}
+	if !strings.Contains(result.error, "no udp echo") {
+		t.Fatalf("expected timeout error, got %q", result.error)
+	}
+}
+
+func TestResolveUDPServer(t *testing.T) {
+	addr, err := resolveUDPServer(context.Background(), "127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("resolveUDPServer failed: %v", err)
+	}
+	if addr.String() != "127.0.0.1:8080" {
+		t.Fatalf("expected 127.0.0.1:8080, got %s", addr)
+	}
+
+	if _, err := resolveUDPServer(context.Background(), "missing-port"); err == nil {
+		t.Fatalf("expected error for address without port")
+	}
+}
+
+func TestResultFormatUDP(t *testing.T) {
+	result := &Result{}
+	if result.FormatUDPSupport() != "N/A" {
+		t.Fatalf("expected untested udp to format as N/A, got %q", result.FormatUDPSupport())
+	}
+
+	result.UDPTested = true
+	if result.FormatUDPSupport() != "No" {
+		t.Fatalf("expected unsupported udp to format as No, got %q", result.FormatUDPSupport())
+	}
+	if result.FormatUDPPacketLoss() != "N/A" {
+		t.Fatalf("expected unsupported udp packet loss to format as N/A, got %q", result.FormatUDPPacketLoss())
+	}
+
+	result.UDPSupported = true
+	result.UDPLatency = 42 * time.Millisecond
+	result.UDPPacketLoss = 20
+	if result.FormatUDPSupport() != "Yes" {
+		t.Fatalf("expected supported udp to format as Yes, got %q", result.FormatUDPSupport())
+	}
+	if result.FormatUDPLatency() != "42ms" {
+		t.Fatalf("expected udp latency 42ms, got %q", result.FormatUDPLatency())
+	}
+	if result.FormatUDPPacketLoss() != "20.0%" {
+		t.Fatalf("expected udp packet loss 20.0%%, got %q", result.FormatUDPPacketLoss())
+	}
+}
@@ -21,6 +21,7 @@ func (m *tuiModel) toggleDetail(result *speedtester.Result) {
 	}
 	m.detailResult = result
 	m.detailVisible = true
+	m.detailOffset = 0
 	m.help.setDetailVisible(true)
 	m.refreshDetailHeight()
 	m.updateTableLayout()
@@ -30,10 +31,56 @@ func (m tuiModel) detailPanelView() string {
 	if !m.detailVisible || m.detailResult == nil {
 		return ""
 	}
+	panelWidth := m.detailPanelWidth()
+	content := strings.Join(m.visibleDetailLines(), "\n")
+	return lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1).Width(panelWidth).Render(content)
+}
+
+// detailLines returns the detail content wrapped to the panel width, one entry per rendered line.
+func (m tuiModel) detailLines() []string {
 	panelWidth := m.detailPanelWidth()
 	contentWidth := max(10, panelWidth-2)
 	content := buildDetailContent(m.detailResult, contentWidth, m.mode)
-	return lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1).Width(panelWidth).Render(content)
+	return strings.Split(lipgloss.NewStyle().Width(contentWidth).Render(content), "\n")
+}
+
+// detailMaxLines is how many content lines fit while the table keeps detailMinTableHeight rows.
+// Zero means the window size is unknown and the panel is not capped.
+func (m tuiModel) detailMaxLines() int {
+	if m.windowHeight == 0 {
+		return 0
+	}
+	reserved := 2 + 1 + detailBorderLines
+	if helpHeight := m.help.height(); helpHeight > 0 {
+		reserved += helpHeight + 1
+	}
+	return max(m.windowHeight-reserved-detailMinTableHeight, detailMinLines)
+}
+
+// visibleDetailLines caps the detail content to detailMaxLines, keeping the last line for a
+// scroll hint so the table is not pushed down to its minimum height by a long panel.
+func (m tuiModel) visibleDetailLines() []string {
+	lines := m.detailLines()
+	limit := m.detailMaxLines()
+	if limit == 0 || len(lines) <= limit {
+		return lines
+	}
+	pageSize := limit - 1
+	offset := clampInt(m.detailOffset, 0, len(lines)-pageSize)
+	visible := append([]string{}, lines[offset:offset+pageSize]...)
+	hint := fmt.Sprintf("Lines %d-%d of %d, shift+↑/↓ to scroll.", offset+1, offset+pageSize, len(lines))
+	return append(visible, hint)
+}
+
+// scrollDetail moves the visible window of an overflowing detail panel by delta lines.
+func (m *tuiModel) scrollDetail(delta int) {
+	limit := m.detailMaxLines()
+	total := len(m.detailLines())
+	if limit == 0 || total <= limit {
+		m.detailOffset = 0
+		return
+	}
+	m.detailOffset = clampInt(m.detailOffset+delta, 0, total-(limit-1))
 }
 
 func (m tuiModel) detailPanelWidth() int {
@@ -72,10 +119,7 @@ func (m tuiModel) calculateDetailHeight() int {
 	if !m.detailVisible || m.detailResult == nil {
 		return 0
 	}
-	panelWidth := m.detailPanelWidth()
-	contentWidth := max(10, panelWidth-2)
-	content := buildDetailContent(m.detailResult, contentWidth, m.mode)
-	return lipgloss.Height(lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1).Width(panelWidth).Render(content))
+	return lipgloss.Height(m.detailPanelView())
 }
 
 func buildDetailContent(result *speedtester.Result, width int, mode speedtester.SpeedMode) string {
@@ -85,6 +129,28 @@ func buildDetailContent(result *speedtester.Result, width int, mode speedtester.
 		"",
 		fmt.Sprintf("Latency: %s", result.FormatLatency()),
 	}
+	// Lines of metrics that were never measured are left out to keep the panel short.
+	if result.LatencyMin > 0 {
+		lines = append(lines, fmt.Sprintf("Min: %s | Median: %s | P90: %s | P99: %s", result.FormatLatencyMin(), result.FormatLatencyMedian(), result.FormatLatencyP90(), result.FormatLatencyP99()))
+	}
+	if result.ProxyDialTime > 0 || result.TLSHandshakeTime > 0 || result.TTFB > 0 {
+		lines = append(lines, fmt.Sprintf("Proxy Dial: %s | TLS Handshake: %s | TTFB: %s", result.FormatProxyDialTime(), result.FormatTLSHandshakeTime(), result.FormatTTFB()))
+	}
+	if result.ExitIP != "" || result.ExitIPError != "" {
+		lines = append(lines, fmt.Sprintf("Exit IP: %s | Entry: %s", result.FormatExitIP(), result.EntryIP()))
+		if result.ExitIPError != "" {
+			lines = appendWrappedValue(lines, "Exit IP Error:", result.ExitIPError, width)
+		}
+	}
+	if result.UDPTested {
+		lines = append(lines,
+			"",
+			fmt.Sprintf("UDP: %s | UDP Latency: %s | UDP Packet Loss: %s", result.FormatUDPSupport(), result.FormatUDPLatency(), result.FormatUDPPacketLoss()),
+		)
+		if result.UDPError != "" {
+			lines = appendWrappedValue(lines, "UDP Error:", result.FormatUDPError(), width)
+		}
+	}
 	if !mode.IsFast() {
 		lines = append(lines,
 			fmt.Sprintf("Jitter: %s", result.FormatJitter()),
@@ -92,9 +158,31 @@ func buildDetailContent(result *speedtester.Result, width int, mode speedtester.
 			"",
 			fmt.Sprintf("Download: %s", result.FormatDownloadSpeedValue()),
 		)
+		if result.TransferTime > 0 {
+			lines = append(lines, fmt.Sprintf("Transfer: %s", result.FormatTransferTime()))
+		}
+		if result.LoadedLatency > 0 {
+			lines = append(lines, fmt.Sprintf("Loaded Latency: %s | Bufferbloat: %s", result.FormatLoadedLatency(), result.FormatBufferbloat()))
+		}
+		lines = appendThroughputStats(lines, result.DownloadStats, width)
+		lines = appendStreams(lines, result.DownloadStreams, width)
+		if len(result.ScalingSteps) > 0 {
+			lines = append(lines, fmt.Sprintf("Single Stream: %s | Best: %s | Gain: %s", result.FormatSingleStreamSpeed(), result.FormatBestStreamSpeed(), result.FormatScalingGain()))
+		}
+		if result.SustainedTested {
+			lines = append(lines, fmt.Sprintf("Sustained: %s | Throttled: %s", result.FormatSustainedSpeed(), result.FormatThrottle()))
+			if result.SustainedError != "" {
+				lines = appendWrappedValue(lines, "Sustained Error:", result.SustainedError, width)
+			}
+		}
 		lines = appendWrappedValue(lines, "Download Error:", result.FormatDownloadError(), width)
+		if result.IntegrityError != "" {
+			lines = appendWrappedValue(lines, "Integrity Error:", result.FormatIntegrityError(), width)
+		}
 		if mode.UploadEnabled() {
 			lines = append(lines, "", fmt.Sprintf("Upload: %s", result.FormatUploadSpeedValue()))
+			lines = appendThroughputStats(lines, result.UploadStats, width)
+			lines = appendStreams(lines, result.UploadStreams, width)
 			lines = appendWrappedValue(lines, "Upload Error:", result.FormatUploadError(), width)
 		}
 	}
@@ -102,6 +190,27 @@ func buildDetailContent(result *speedtester.Result, width int, mode speedtester.
 	return strings.Join(lines, "\n")
 }
 
+func appendThroughputStats(lines []string, stats speedtester.ThroughputStats, width int) []string {
+	if len(stats.Samples) == 0 {
+		return lines
+	}
+	lines = append(lines, fmt.Sprintf("Peak: %s | Steady: %s | Stalls: %s", stats.FormatPeakSpeed(), stats.FormatSteadySpeed(), stats.FormatStalls()))
+	prefix := "Samples: "
+	return append(lines, prefix+renderSparkline(stats.Samples, max(width-lipgloss.Width(prefix), 10)))
+}
+
+// appendStreams lists per-stream speeds so a single failed or slow stream is visible.
+func appendStreams(lines []string, streams []speedtester.StreamResult, width int) []string {
+	if len(streams) < 2 {
+		return lines
+	}
+	parts := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		parts = append(parts, fmt.Sprintf("#%d %s", stream.Stream, stream.FormatSpeed()))
+	}
+	return appendWrappedValue(lines, "Streams:", strings.Join(parts, " | "), width)
+}
+
 func appendWrappedValue(lines []string, label, value string, width int) []string {
 	if value == "" {
 		value = "N/A"
@@ -222,3 +222,245 @@ func TestDetailPanelHeightUpdatesOnSelectionChange(t *testing.T) {
 		t.Fatalf("expected table height to change after detail content update: initial=%d updated=%d", initialHeight, updatedHeight)
 	}
 }
+
+func TestDetailPanelHeightUpdatesInTallWindow(t *testing.T) {
+	resultChannel := make(chan *speedtester.Result, 10)
+	model := NewTUIModel(speedtester.SpeedModeFull, 2, resultChannel)
+	model.windowWidth = 80
+	model.windowHeight = 50
+
+	shortResult := &speedtester.Result{ProxyName: "Short", ProxyType: "SS", Latency: 120 * time.Millisecond}
+	longResult := &speedtester.Result{
+		ProxyName:     "Long",
+		ProxyType:     "Trojan",
+		Latency:       180 * time.Millisecond,
+		LatencyMin:    150 * time.Millisecond,
+		ProxyDialTime: 90 * time.Millisecond,
+		TransferTime:  3 * time.Second,
+		LoadedLatency: 400 * time.Millisecond,
+		DownloadError: strings.Repeat("download error ", 8),
+		UploadError:   strings.Repeat("upload error ", 8),
+	}
+
+	model.results = []*speedtester.Result{shortResult, longResult}
+	model.updateTableRows()
+	model.detailVisible = true
+	model.detailResult = shortResult
+	model.refreshDetailHeight()
+	model.updateTableLayout()
+	initialHeight := model.table.Height()
+
+	model.table.SetCursor(1)
+	model.syncSelectionFromCursor()
+	if model.table.Height() >= initialHeight {
+		t.Fatalf("expected table to shrink for the longer detail panel: initial=%d updated=%d", initialHeight, model.table.Height())
+	}
+	if strings.Contains(model.detailPanelView(), "to scroll") {
+		t.Fatalf("expected detail panel to fit a 50-row window without scrolling, got %q", model.detailPanelView())
+	}
+}
+
+func TestDetailPanelCapsAndScrolls(t *testing.T) {
+	resultChannel := make(chan *speedtester.Result, 10)
+	model := NewTUIModel(speedtester.SpeedModeFull, 1, resultChannel)
+	model.windowWidth = 80
+	model.windowHeight = 30
+
+	result := &speedtester.Result{
+		ProxyName:     "Measured",
+		ProxyType:     "Vless",
+		Latency:       180 * time.Millisecond,
+		LatencyMin:    150 * time.Millisecond,
+		ProxyDialTime: 90 * time.Millisecond,
+		ExitIP:        "5.6.7.8",
+		UDPTested:     true,
+		TransferTime:  3 * time.Second,
+		LoadedLatency: 400 * time.Millisecond,
+		DownloadError: strings.Repeat("download error ", 8),
+		UploadError:   strings.Repeat("upload error ", 8),
+	}
+	model.results = []*speedtester.Result{result}
+	model.updateTableRows()
+	model.updateTableLayout()
+	model.toggleDetail(result)
+
+	if height := model.table.Height(); height < detailMinTableHeight-tableHeaderLines {
+		t.Fatalf("expected capped detail panel to leave the table %d rows, got %d", detailMinTableHeight-tableHeaderLines, height)
+	}
+	view := model.detailPanelView()
+	if !strings.Contains(view, "Lines 1-") || strings.Contains(view, "Press ESC") {
+		t.Fatalf("expected capped panel to show its first lines and a scroll hint, got %q", view)
+	}
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	model = updated.(tuiModel)
+	if model.detailOffset != 1 || !strings.Contains(model.detailPanelView(), "Lines 2-") {
+		t.Fatalf("expected shift+down to scroll the panel, got offset %d", model.detailOffset)
+	}
+	for range 50 {
+		model.scrollDetail(1)
+	}
+	if !strings.Contains(model.detailPanelView(), "Press ESC") {
+		t.Fatalf("expected scrolling to reach the end of the details, got %q", model.detailPanelView())
+	}
+}
+
+func TestBuildDetailContentPhaseTimings(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:        "Traced Proxy",
+		ProxyType:        "Vless",
+		Latency:          250 * time.Millisecond,
+		ProxyDialTime:    120 * time.Millisecond,
+		TLSHandshakeTime: 80 * time.Millisecond,
+		TTFB:             40 * time.Millisecond,
+		TransferTime:     3 * time.Second,
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	for _, expected := range []string{"Proxy Dial: 120ms", "TLS Handshake: 80ms", "TTFB: 40ms", "Transfer: 3000ms"} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("expected detail content to include %q, got %q", expected, content)
+		}
+	}
+
+	fastContent := buildDetailContent(result, 100, speedtester.SpeedModeFast)
+	if strings.Contains(fastContent, "Transfer:") {
+		t.Fatalf("expected fast mode detail to omit transfer time, got %q", fastContent)
+	}
+}
+
+func TestBuildDetailContentLatencyDistribution(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:     "Probe Proxy",
+		ProxyType:     "Trojan",
+		Latency:       150 * time.Millisecond,
+		LatencyMin:    100 * time.Millisecond,
+		LatencyMedian: 140 * time.Millisecond,
+		LatencyP90:    220 * time.Millisecond,
+		LatencyP99:    260 * time.Millisecond,
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeFast)
+	expected := "Min: 100ms | Median: 140ms | P90: 220ms | P99: 260ms"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentThroughputStats(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:     "Bursty Proxy",
+		ProxyType:     "Trojan",
+		Latency:       150 * time.Millisecond,
+		DownloadSpeed: 10 * 1024 * 1024,
+		DownloadStats: speedtester.ThroughputStats{
+			PeakSpeed:     50 * 1024 * 1024,
+			SteadySpeed:   12 * 1024 * 1024,
+			StallCount:    1,
+			StallDuration: 4 * time.Second,
+			Samples:       []float64{50 * 1024 * 1024, 0, 0, 25 * 1024 * 1024},
+		},
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	for _, expected := range []string{"Peak: 50.00MB/s", "Steady: 12.00MB/s", "Stalls: 1 (4s)", "Samples: █▁▁▄"} {
+		if !strings.Contains(content, expected) {
+			t.Fatalf("expected detail content to include %q, got %q", expected, content)
+		}
+	}
+}
+
+func TestBuildDetailContentLoadedLatency(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:        "Bloated Proxy",
+		ProxyType:        "Vmess",
+		Latency:          150 * time.Millisecond,
+		LoadedLatency:    420 * time.Millisecond,
+		BufferbloatDelta: 270 * time.Millisecond,
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	expected := "Loaded Latency: 420ms | Bufferbloat: +270ms"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentStreams(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:     "Multi Stream Proxy",
+		ProxyType:     "Trojan",
+		Latency:       150 * time.Millisecond,
+		DownloadSpeed: 10 * 1024 * 1024,
+		DownloadStreams: []speedtester.StreamResult{
+			{Stream: 1, Bytes: 1024, Speed: 5 * 1024 * 1024},
+			{Stream: 2, Error: "download request failed"},
+		},
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	expected := "Streams: #1 5.00MB/s | #2 failed"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentConcurrencyScaling(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:         "Per Flow Capped",
+		ProxyType:         "Shadowsocks",
+		Latency:           150 * time.Millisecond,
+		SingleStreamSpeed: 2 * 1024 * 1024,
+		BestStreamSpeed:   8 * 1024 * 1024,
+		BestStreamCount:   4,
+		ScalingSteps:      []speedtester.ScalingStep{{Streams: 1}, {Streams: 4}},
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	expected := "Single Stream: 2.00MB/s | Best: 8.00MB/s @ 4 streams | Gain: x4.00"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentSustained(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:             "Throttled Proxy",
+		ProxyType:             "Vmess",
+		Latency:               150 * time.Millisecond,
+		SustainedTested:       true,
+		SustainedInitialSpeed: 20 * 1024 * 1024,
+		SustainedFinalSpeed:   1024 * 1024,
+		Throttled:             true,
+		ThrottleBytes:         200 * 1024 * 1024,
+		ThrottleAfter:         10 * time.Second,
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeSustained)
+	expected := "Sustained: 20.00MB/s -> 1.00MB/s | Throttled: after 200.00MB (10s)"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentIntegrityError(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:      "Tampering Proxy",
+		ProxyType:      "Http",
+		Latency:        150 * time.Millisecond,
+		IntegrityError: "request headers altered: injected Via",
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	expected := "Integrity Error: request headers altered: injected Via"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
+
+func TestBuildDetailContentExitIP(t *testing.T) {
+	result := &speedtester.Result{
+		ProxyName:   "Relay Proxy",
+		ProxyType:   "Trojan",
+		ProxyConfig: map[string]any{"server": "relay.example.com"},
+		Latency:     150 * time.Millisecond,
+		ExitIP:      "203.0.113.7",
+	}
+	content := buildDetailContent(result, 100, speedtester.SpeedModeDownload)
+	expected := "Exit IP: 203.0.113.7 | Entry: relay.example.com"
+	if !strings.Contains(content, expected) {
+		t.Fatalf("expected detail content to include %q, got %q", expected, content)
+	}
+}
@@ -13,9 +13,10 @@ type helpState struct {
 }
 
 type helpKeyMap struct {
-	Quit        key.Binding
-	CloseDetail key.Binding
-	Table       table.KeyMap
+	Quit         key.Binding
+	CloseDetail  key.Binding
+	ScrollDetail key.Binding
+	Table        table.KeyMap
 }
 
 func newHelpState(tableKeys table.KeyMap) helpState {
@@ -31,6 +32,10 @@ func newHelpState(tableKeys table.KeyMap) helpState {
 				key.WithKeys("esc"),
 				key.WithHelp("esc", "close details"),
 			),
+			ScrollDetail: key.NewBinding(
+				key.WithKeys("shift+up", "shift+down"),
+				key.WithHelp("shift+↑/↓", "scroll details"),
+			),
 		},
 	}
 	state.setDetailVisible(false)
@@ -43,6 +48,7 @@ func (h *helpState) setWidth(width int) {
 
 func (h *helpState) setDetailVisible(visible bool) {
 	h.keyMap.CloseDetail.SetEnabled(visible)
+	h.keyMap.ScrollDetail.SetEnabled(visible)
 }
 
 func (h helpState) view() string {
@@ -63,6 +69,7 @@ func (km helpKeyMap) ShortHelp() []key.Binding {
 		km.Table.LineDown,
 		km.Quit,
 		km.CloseDetail,
+		km.ScrollDetail,
 	}
 }
 
@@ -70,6 +77,6 @@ func (km helpKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{km.Table.LineUp, km.Table.LineDown, km.Table.GotoTop, km.Table.GotoBottom},
 		{km.Table.PageUp, km.Table.PageDown, km.Table.HalfPageUp, km.Table.HalfPageDown},
-		{km.CloseDetail, km.Quit},
+		{km.CloseDetail, km.ScrollDetail, km.Quit},
 	}
 }
@@ -54,6 +54,7 @@ type tuiModel struct {
 	resultsDirty   bool
 	flushScheduled bool
 	detailHeight   int
+	detailOffset   int
 	perf           *perfTracker
 }
 
@@ -63,6 +64,10 @@ const (
 	detailPanelMinWidth = 60
 	defaultDetailWidth  = 80
 	resultFlushInterval = 100 * time.Millisecond
+	// detailMinTableHeight is the table height kept when the detail panel is capped.
+	detailMinTableHeight = 8
+	detailMinLines       = 4
+	detailBorderLines    = 2
 )
 
 var selectedRowStyle = lipgloss.NewStyle().
@@ -178,6 +183,16 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.updateTableLayout()
 				return m, nil
 			}
+		case "shift+down":
+			if m.detailVisible {
+				m.scrollDetail(1)
+				return m, nil
+			}
+		case "shift+up":
+			if m.detailVisible {
+				m.scrollDetail(-1)
+				return m, nil
+			}
 		case "q", "ctrl+c":
 			m.quitting = true
 			return m, tea.Quit
@@ -0,0 +1,41 @@
+package tui
+
+import "strings"
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws samples scaled to their peak, averaging neighbours
+// when there are more samples than the available width.
+func renderSparkline(samples []float64, width int) string {
+	if len(samples) == 0 || width <= 0 {
+		return ""
+	}
+	buckets := samples
+	if len(samples) > width {
+		buckets = make([]float64, width)
+		for i := range buckets {
+			start := i * len(samples) / width
+			end := max((i+1)*len(samples)/width, start+1)
+			var total float64
+			for _, sample := range samples[start:end] {
+				total += sample
+			}
+			buckets[i] = total / float64(end-start)
+		}
+	}
+
+	var peak float64
+	for _, value := range buckets {
+		peak = max(peak, value)
+	}
+	var builder strings.Builder
+	for _, value := range buckets {
+		level := 0
+		if peak > 0 {
+			level = int(value / peak * float64(len(sparklineLevels)-1))
+		}
+		level = max(min(level, len(sparklineLevels)-1), 0)
+		builder.WriteRune(sparklineLevels[level])
+	}
+	return builder.String()
+}
@@ -0,0 +1,22 @@
+package tui
+
+import "testing"
+
+func TestRenderSparkline(t *testing.T) {
+	if got := renderSparkline(nil, 10); got != "" {
+		t.Fatalf("expected empty sparkline for no samples, got %q", got)
+	}
+
+	got := renderSparkline([]float64{0, 50, 100}, 10)
+	if got != "▁▄█" {
+		t.Fatalf("expected scaled sparkline, got %q", got)
+	}
+}
+
+func TestRenderSparklineDownsamples(t *testing.T) {
+	samples := []float64{100, 100, 0, 0, 100, 100}
+	got := renderSparkline(samples, 3)
+	if got != "█▁█" {
+		t.Fatalf("expected averaged sparkline, got %q", got)
+	}
+}
@@ -251,6 +251,7 @@ func (m *tuiModel) syncSelectionFromCursor() {
 		if m.detailResult != m.results[cursor] {
 			previousHeight := m.detailHeight
 			m.detailResult = m.results[cursor]
+			m.detailOffset = 0
 			m.refreshDetailHeight()
 			if m.detailHeight != previousHeight {
 				// Keep layout in sync when detail content height changes on scroll.